@@ -0,0 +1,207 @@
+package tray
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Caller describes the sender of an incoming D-Bus call. It is passed
+// to an [AccessPolicy] to decide whether or not the call should be
+// allowed.
+type Caller struct {
+	// Name is the unique bus name of the sender, such as ":1.42".
+	Name string
+
+	// Method is the name of the D-Bus method that was called, such as
+	// "Activate" or "Event".
+	Method string
+
+	// PID and UID are the process and user IDs of the sender as
+	// reported by the bus. They are zero if they could not be
+	// determined.
+	PID, UID uint32
+
+	// IsHost is true if the sender is the StatusNotifierWatcher that
+	// the item was registered with or a StatusNotifierHost.
+	IsHost bool
+}
+
+// AccessPolicy is a function that decides whether or not an incoming
+// call should be allowed. If it returns a non-nil error, the call is
+// rejected with [ErrorAccessDenied] and no handlers are called.
+type AccessPolicy func(caller Caller) error
+
+// ErrNotHost is returned by [HostOnly] for callers that are not the
+// host.
+var ErrNotHost = errors.New("caller is not a StatusNotifierHost")
+
+// HostOnly is an AccessPolicy that only allows calls from the
+// StatusNotifierWatcher and StatusNotifierHost processes.
+func HostOnly(caller Caller) error {
+	if !caller.IsHost {
+		return ErrNotHost
+	}
+	return nil
+}
+
+// ItemAccessPolicy sets the policy that is consulted before handling
+// calls that trigger handlers, both on the item and on its menu. A nil
+// policy, the default, allows all calls.
+func ItemAccessPolicy(policy AccessPolicy) ItemProp {
+	return func(item *itemProps) {
+		p := &policy
+		if policy == nil {
+			p = nil
+		}
+		item.accessPolicy.Store(p)
+	}
+}
+
+var hostNamePrefixes = [...]string{
+	"org.kde.StatusNotifierHost-",
+	"org.freedesktop.StatusNotifierHost-",
+}
+
+type callerInfo struct {
+	pid, uid uint32
+
+	m      sync.Mutex
+	isHost bool
+}
+
+func (item *Item) checkAccess(sender dbus.Sender, method string) *dbus.Error {
+	policy := item.accessPolicy.Load()
+	if policy == nil {
+		return nil
+	}
+
+	caller := item.lookupCaller(string(sender))
+	caller.Method = method
+
+	err := (*policy)(caller)
+	if err != nil {
+		logger.Warn("access denied", "caller", caller, "err", err)
+		return dbus.NewError(ErrorAccessDenied, []any{err.Error()})
+	}
+	return nil
+}
+
+func (item *Item) lookupCaller(sender string) Caller {
+	v, ok := item.callers.Load(sender)
+	if !ok {
+		info := callerInfo{}
+		bus := item.conn.BusObject()
+
+		err := dbusCall(bus, "org.freedesktop.DBus.GetConnectionUnixProcessID", 0, sender).Store(&info.pid)
+		if err != nil {
+			logger.Warn("get caller PID failed", "sender", sender, "err", err)
+		}
+
+		err = dbusCall(bus, "org.freedesktop.DBus.GetConnectionUnixUser", 0, sender).Store(&info.uid)
+		if err != nil {
+			logger.Warn("get caller UID failed", "sender", sender, "err", err)
+		}
+
+		v, _ = item.callers.LoadOrStore(sender, &info)
+	}
+	info := v.(*callerInfo)
+
+	return Caller{
+		Name:   sender,
+		PID:    info.pid,
+		UID:    info.uid,
+		IsHost: item.isHost(sender, info),
+	}
+}
+
+// isHost checks if sender owns either the name of the watcher that
+// the item was registered with or the name of any StatusNotifierHost.
+// Unique names are never reused, so a positive result is cached, but a
+// negative one is not as the sender could still become a host later.
+func (item *Item) isHost(sender string, info *callerInfo) bool {
+	info.m.Lock()
+	defer info.m.Unlock()
+
+	if info.isHost {
+		return true
+	}
+
+	if watcher := item.watcher.Load(); watcher != nil && item.ownsName(sender, *watcher) {
+		info.isHost = true
+		return true
+	}
+
+	var names []string
+	err := dbusCall(item.conn.BusObject(), "org.freedesktop.DBus.ListNames", 0).Store(&names)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		for _, prefix := range hostNamePrefixes {
+			if strings.HasPrefix(name, prefix) && item.ownsName(sender, name) {
+				info.isHost = true
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// watchCallers starts watching for callers disconnecting from the bus
+// so that the information kept about them can be discarded.
+func (item *Item) watchCallers() error {
+	err := item.conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchObjectPath("/org/freedesktop/DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(2, ""),
+	)
+	if err != nil {
+		return err
+	}
+
+	c := make(chan *dbus.Signal, 16)
+	item.conn.Signal(c)
+	go item.handleCallerSignals(c)
+
+	return nil
+}
+
+func (item *Item) handleCallerSignals(c <-chan *dbus.Signal) {
+	for sig := range c {
+		if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) != 3 {
+			continue
+		}
+
+		name, _ := sig.Body[0].(string)
+		newOwner, _ := sig.Body[2].(string)
+		if !strings.HasPrefix(name, ":") || newOwner != "" {
+			continue
+		}
+
+		item.forgetCaller(name)
+	}
+}
+
+// forgetCaller discards everything known about the caller with the
+// given unique name after it disconnects.
+func (item *Item) forgetCaller(name string) {
+	logger.Info("caller disconnected", "name", name)
+
+	item.callers.Delete(name)
+
+	menu := item.menu
+	menu.m.Lock()
+	menu.dirty.forget(name)
+	menu.m.Unlock()
+
+	c := &menu.compat
+	c.m.Lock()
+	delete(c.fullProps, name)
+	c.m.Unlock()
+}
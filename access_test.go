@@ -0,0 +1,75 @@
+package tray
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestHandleCallerSignals(t *testing.T) {
+	nameOwnerChanged := "org.freedesktop.DBus.NameOwnerChanged"
+
+	tests := []struct {
+		name      string
+		sig       *dbus.Signal
+		forgotten bool
+	}{
+		{
+			name:      "Disconnected",
+			sig:       &dbus.Signal{Name: nameOwnerChanged, Body: []any{":1.5", ":1.5", ""}},
+			forgotten: true,
+		},
+		{
+			name: "NewOwner",
+			sig:  &dbus.Signal{Name: nameOwnerChanged, Body: []any{":1.5", "", ":1.5"}},
+		},
+		{
+			name: "WellKnownName",
+			sig:  &dbus.Signal{Name: nameOwnerChanged, Body: []any{"org.example.Name", ":1.5", ""}},
+		},
+		{
+			name: "OtherSignal",
+			sig:  &dbus.Signal{Name: "org.freedesktop.DBus.NameLost", Body: []any{":1.5"}},
+		},
+		{
+			name: "ShortBody",
+			sig:  &dbus.Signal{Name: nameOwnerChanged, Body: []any{":1.5"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, _ := newTestItem(t)
+			item.callers.Store(":1.5", &callerInfo{pid: 5})
+			item.callers.Store(":1.6", &callerInfo{pid: 6})
+			item.menu.compat.fullProps = map[string]bool{":1.5": true, ":1.6": true}
+			item.menu.dirty.markSeen(":1.5", 0)
+			item.menu.dirty.markSeen(":1.6", 0)
+
+			c := make(chan *dbus.Signal, 1)
+			c <- test.sig
+			close(c)
+			item.handleCallerSignals(c)
+
+			_, ok := item.callers.Load(":1.5")
+			if ok == test.forgotten {
+				t.Errorf("caller :1.5 cached = %v; want %v", ok, !test.forgotten)
+			}
+			_, ok = item.menu.compat.fullProps[":1.5"]
+			if ok == test.forgotten {
+				t.Errorf("compatibility of caller :1.5 cached = %v; want %v", ok, !test.forgotten)
+			}
+			_, ok = item.menu.dirty.seen[":1.5"]
+			if ok == test.forgotten {
+				t.Errorf("layouts sent to caller :1.5 tracked = %v; want %v", ok, !test.forgotten)
+			}
+
+			_, ok1 := item.callers.Load(":1.6")
+			_, ok2 := item.menu.compat.fullProps[":1.6"]
+			_, ok3 := item.menu.dirty.seen[":1.6"]
+			if !ok1 || !ok2 || !ok3 {
+				t.Errorf("unrelated caller was forgotten")
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package tray
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"deedles.dev/tray/internal/set"
+)
+
+// menuChanges collects layout and property changes so that they can be
+// emitted together instead of as they happen.
+type menuChanges struct {
+	layout  []int
+	seen    set.Set[int]
+	updated []menuProps
+	removed []menuRemovedProps
+	errs    []error
+}
+
+func (c *menuChanges) markLayout(id int) {
+	if c.seen == nil {
+		c.seen = make(set.Set[int])
+	}
+	if c.seen.Contains(id) {
+		return
+	}
+	c.seen.Add(id)
+	c.layout = append(c.layout, id)
+}
+
+// addProps merges property changes into the collected ones. Later
+// changes to a property override earlier ones, including a removal
+// overriding an update and vice versa.
+func (c *menuChanges) addProps(updated []menuProps, removed []menuRemovedProps) {
+	for _, u := range updated {
+		if len(u.Properties) == 0 {
+			continue
+		}
+
+		i := slices.IndexFunc(c.updated, func(p menuProps) bool { return p.ID == u.ID })
+		if i < 0 {
+			c.updated = append(c.updated, menuProps{ID: u.ID, Properties: maps.Clone(u.Properties)})
+		} else {
+			maps.Copy(c.updated[i].Properties, u.Properties)
+		}
+
+		i = slices.IndexFunc(c.removed, func(p menuRemovedProps) bool { return p.ID == u.ID })
+		if i >= 0 {
+			c.removed[i].Properties = slices.DeleteFunc(c.removed[i].Properties, func(name string) bool {
+				_, ok := u.Properties[name]
+				return ok
+			})
+		}
+	}
+
+	for _, r := range removed {
+		i := slices.IndexFunc(c.updated, func(p menuProps) bool { return p.ID == r.ID })
+		if i >= 0 {
+			for _, name := range r.Properties {
+				delete(c.updated[i].Properties, name)
+			}
+		}
+
+		i = slices.IndexFunc(c.removed, func(p menuRemovedProps) bool { return p.ID == r.ID })
+		if i < 0 {
+			c.removed = append(c.removed, menuRemovedProps{ID: r.ID, Properties: slices.Clone(r.Properties)})
+			continue
+		}
+		for _, name := range r.Properties {
+			if !slices.Contains(c.removed[i].Properties, name) {
+				c.removed[i].Properties = append(c.removed[i].Properties, name)
+			}
+		}
+	}
+}
+
+// flush emits a LayoutUpdated signal for each of the given IDs and a
+// single ItemsPropertiesUpdated signal for all of the collected
+// property changes to items that are still in the menu.
+func (c *menuChanges) flush(menu *Menu, layout ...int) error {
+	errs := c.errs
+	if len(layout) != 0 {
+		errs = append(errs, menu.emitLayoutUpdated(layout...))
+	}
+
+	attached := func(id int) bool { return menu.nodes[id] != nil }
+	updated := slices.DeleteFunc(c.updated, func(p menuProps) bool {
+		return len(p.Properties) == 0 || !attached(p.ID)
+	})
+	removed := slices.DeleteFunc(c.removed, func(p menuRemovedProps) bool {
+		return len(p.Properties) == 0 || !attached(p.ID)
+	})
+	if len(updated) != 0 || len(removed) != 0 {
+		errs = append(errs, menu.emitItemsPropertiesUpdated(updated, removed))
+	}
+
+	return errors.Join(errs...)
+}
+
+// commonAncestor returns the ID of the deepest node that is either
+// one of the nodes with the given IDs or an ancestor of all of them.
+// IDs of nodes that are no longer in the menu are ignored. The menu
+// must be at least read locked.
+func (menu *Menu) commonAncestor(ids []int) int {
+	var common []int
+	for _, id := range ids {
+		path := menu.pathToRoot(id)
+		if path == nil {
+			continue
+		}
+		if common == nil {
+			common = path
+			continue
+		}
+		common = slices.DeleteFunc(common, func(id int) bool { return !slices.Contains(path, id) })
+	}
+
+	if len(common) == 0 {
+		return 0
+	}
+	return common[0]
+}
+
+// pathToRoot returns the IDs of the node with the given ID and all of
+// its ancestors, ending with the root. It returns nil if the node is
+// not in the menu.
+func (menu *Menu) pathToRoot(id int) []int {
+	path := []int{id}
+	for id != 0 {
+		item := menu.nodes[id]
+		if item == nil {
+			return nil
+		}
+		id = item.parent
+		path = append(path, id)
+	}
+	return path
+}
+
+// MenuTx is a set of changes to a menu that are applied as a single
+// update. It is only valid for the duration of the function passed to
+// [Menu.Batch].
+//
+// The methods of MenuTx do not change the menu immediately. Instead,
+// they record the change, which is then made when the transaction is
+// applied. Apart from that, and that methods that take a parent
+// *MenuItem use the root of the menu if parent is nil, each method
+// does the same thing as the method of the same name on [Menu] or
+// [MenuItem]. The errors that they return only concern recording the
+// change. Errors from making it are returned by Batch.
+type MenuTx struct {
+	menu *Menu
+
+	m    sync.Mutex
+	ops  []func() error
+	done bool
+}
+
+// Batch calls f with a MenuTx that can be used to make any number of
+// changes to the menu and then applies all of them at once. Instead of
+// being emitted as they happen, the changes are combined. The layout
+// revision is incremented once, a single LayoutUpdated signal is
+// emitted for the deepest item that contains all of the structural
+// changes, and a single ItemsPropertiesUpdated signal is emitted for
+// all of the property changes.
+//
+// The menu is not locked while f runs, so f can use the rest of the
+// API as usual, but changes made other than through the MenuTx are
+// not part of the batch. As the recorded changes are only made after
+// f returns, f sees the menu as it was before them. In particular,
+// items created with the MenuTx are not part of the menu until then,
+// though they can be passed to other methods of the MenuTx.
+//
+// The recorded changes are made in order, and an error from one of
+// them does not prevent the rest from being made. All such errors are
+// returned together. If f returns an error or panics, none of the
+// recorded changes are made.
+func (menu *Menu) Batch(f func(tx *MenuTx) error) error {
+	tx := MenuTx{menu: menu}
+	err := tx.run(f)
+	if err != nil {
+		return err
+	}
+	return tx.apply()
+}
+
+// run calls f with tx and marks tx as done when f returns or panics.
+func (tx *MenuTx) run(f func(tx *MenuTx) error) error {
+	defer func() {
+		tx.m.Lock()
+		defer tx.m.Unlock()
+
+		tx.done = true
+	}()
+
+	return f(tx)
+}
+
+// apply makes the recorded changes with the menu locked and emits
+// them together.
+func (tx *MenuTx) apply() (err error) {
+	menu := tx.menu
+	defer menu.lock()()
+
+	c := menuChanges{}
+	menu.batch = &c
+
+	defer func() {
+		menu.batch = nil
+
+		var layout []int
+		if len(c.layout) != 0 {
+			layout = []int{menu.commonAncestor(c.layout)}
+		}
+		err = errors.Join(err, c.flush(menu, layout...))
+	}()
+
+	errs := make([]error, 0, len(tx.ops))
+	for _, op := range tx.ops {
+		errs = append(errs, op())
+	}
+	return errors.Join(errs...)
+}
+
+// ErrTxDone is returned by the methods of a [MenuTx] that is used
+// after the function passed to [Menu.Batch] has returned.
+var ErrTxDone = errors.New("menu transaction has already finished")
+
+// record adds op to the changes to make when the transaction is
+// applied. op is called with the menu locked.
+func (tx *MenuTx) record(op func() error, items ...*MenuItem) error {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	if tx.done {
+		return ErrTxDone
+	}
+	for _, item := range items {
+		if item != nil && item.menu != tx.menu {
+			return ErrDifferentMenus
+		}
+	}
+
+	tx.ops = append(tx.ops, op)
+	return nil
+}
+
+func (tx *MenuTx) node(parent *MenuItem) menuNode {
+	if parent == nil {
+		return tx.menu
+	}
+	return parent
+}
+
+// siblingParent returns the parent of sibling. The menu must be
+// locked.
+func siblingParent(sibling *MenuItem) (menuNode, error) {
+	if err := sibling.checkAttached(); err != nil {
+		return nil, err
+	}
+	return sibling.getParent(), nil
+}
+
+// addChild records the creation of a new MenuItem as a child of the
+// node returned by parent at the position given by pos. The new item
+// is returned immediately so that it can be used in later changes.
+func (tx *MenuTx) addChild(parent func() (menuNode, error), pos func([]int) int, props []MenuItemProp, items ...*MenuItem) (*MenuItem, error) {
+	tx.menu.m.Lock()
+	child := tx.menu.allocItem()
+	tx.menu.m.Unlock()
+
+	err := tx.record(func() error {
+		p, err := parent()
+		if err != nil {
+			return err
+		}
+		if err := checkNode(p); err != nil {
+			return err
+		}
+		return insertChild(tx.menu, p, child, pos, props)
+	}, items...)
+	if err != nil {
+		return nil, err
+	}
+	return child, nil
+}
+
+// AddChild creates a new MenuItem as the last child of parent.
+func (tx *MenuTx) AddChild(parent *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	return tx.addChild(func() (menuNode, error) { return tx.node(parent), nil }, appendPos, props, parent)
+}
+
+// InsertChild creates a new MenuItem at the given index among the
+// children of parent.
+func (tx *MenuTx) InsertChild(parent *MenuItem, index int, props ...MenuItemProp) (*MenuItem, error) {
+	return tx.addChild(func() (menuNode, error) { return tx.node(parent), nil }, indexPos(index), props, parent)
+}
+
+// AddChildBefore creates a new MenuItem immediately before sibling.
+func (tx *MenuTx) AddChildBefore(sibling *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	return tx.addChild(func() (menuNode, error) { return siblingParent(sibling) }, siblingPos(sibling, 0), props, sibling)
+}
+
+// AddChildAfter creates a new MenuItem immediately after sibling.
+func (tx *MenuTx) AddChildAfter(sibling *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	return tx.addChild(func() (menuNode, error) { return siblingParent(sibling) }, siblingPos(sibling, 1), props, sibling)
+}
+
+// Remove removes item from the menu.
+func (tx *MenuTx) Remove(item *MenuItem) error {
+	return tx.record(item.remove, item)
+}
+
+// AppendChild makes child the last child of parent.
+func (tx *MenuTx) AppendChild(parent, child *MenuItem) error {
+	return tx.record(func() error {
+		return moveChild(tx.menu, tx.node(parent), child, appendPos)
+	}, parent, child)
+}
+
+// MoveToIndex moves child to the given index among the children of
+// parent.
+func (tx *MenuTx) MoveToIndex(parent, child *MenuItem, index int) error {
+	return tx.record(func() error {
+		return moveChild(tx.menu, tx.node(parent), child, indexPos(index))
+	}, parent, child)
+}
+
+// MoveBefore makes item the previous sibling of sibling.
+func (tx *MenuTx) MoveBefore(item, sibling *MenuItem) error {
+	return tx.record(func() error { return item.siblingMove(sibling, 0) }, item, sibling)
+}
+
+// MoveAfter makes item the next sibling of sibling.
+func (tx *MenuTx) MoveAfter(item, sibling *MenuItem) error {
+	return tx.record(func() error { return item.siblingMove(sibling, 1) }, item, sibling)
+}
+
+// SortChildren sorts the children of parent using less. less is
+// called with the menu locked when the transaction is applied, so it
+// must not modify the menu.
+func (tx *MenuTx) SortChildren(parent *MenuItem, less func(a, b *MenuItem) bool) error {
+	return tx.record(func() error {
+		return sortChildren(tx.menu, tx.node(parent), less)
+	}, parent)
+}
+
+// SetProps sets all of the given properties on item.
+func (tx *MenuTx) SetProps(item *MenuItem, props ...MenuItemProp) error {
+	return tx.record(func() error { return item.setProps(props) }, item)
+}
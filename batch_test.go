@@ -0,0 +1,333 @@
+package tray
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMenuChangesAddProps(t *testing.T) {
+	type change struct {
+		updated []menuProps
+		removed []menuRemovedProps
+	}
+
+	tests := []struct {
+		name    string
+		changes []change
+		updated []menuProps
+		removed []menuRemovedProps
+	}{
+		{
+			name: "Merge",
+			changes: []change{
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 1}}}},
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{"b": 2}}, {ID: 2, Properties: map[string]any{"a": 3}}}},
+			},
+			updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 1, "b": 2}}, {ID: 2, Properties: map[string]any{"a": 3}}},
+		},
+		{
+			name: "Override",
+			changes: []change{
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 1}}}},
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 2}}}},
+			},
+			updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 2}}},
+		},
+		{
+			name: "Empty",
+			changes: []change{
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{}}}},
+			},
+		},
+		{
+			name: "RemoveAfterUpdate",
+			changes: []change{
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 1, "b": 2}}}},
+				{removed: []menuRemovedProps{{ID: 1, Properties: []string{"a"}}}},
+			},
+			updated: []menuProps{{ID: 1, Properties: map[string]any{"b": 2}}},
+			removed: []menuRemovedProps{{ID: 1, Properties: []string{"a"}}},
+		},
+		{
+			name: "UpdateAfterRemove",
+			changes: []change{
+				{removed: []menuRemovedProps{{ID: 1, Properties: []string{"a", "b"}}}},
+				{updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 3}}}},
+			},
+			updated: []menuProps{{ID: 1, Properties: map[string]any{"a": 3}}},
+			removed: []menuRemovedProps{{ID: 1, Properties: []string{"b"}}},
+		},
+		{
+			name: "RemoveTwice",
+			changes: []change{
+				{removed: []menuRemovedProps{{ID: 1, Properties: []string{"a"}}}},
+				{removed: []menuRemovedProps{{ID: 1, Properties: []string{"a", "b"}}, {ID: 2, Properties: []string{"c"}}}},
+			},
+			removed: []menuRemovedProps{{ID: 1, Properties: []string{"a", "b"}}, {ID: 2, Properties: []string{"c"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var c menuChanges
+			for _, change := range test.changes {
+				c.addProps(change.updated, change.removed)
+			}
+			if !reflect.DeepEqual(c.updated, test.updated) {
+				t.Errorf("updated = %v; want %v", c.updated, test.updated)
+			}
+			if !reflect.DeepEqual(c.removed, test.removed) {
+				t.Errorf("removed = %v; want %v", c.removed, test.removed)
+			}
+		})
+	}
+}
+
+func TestMenuChangesAddPropsCopies(t *testing.T) {
+	updated := []menuProps{{ID: 1, Properties: map[string]any{"a": 1}}}
+	removed := []menuRemovedProps{{ID: 2, Properties: []string{"b"}}}
+
+	var c menuChanges
+	c.addProps(updated, removed)
+	updated[0].Properties["a"] = 2
+	removed[0].Properties[0] = "c"
+
+	if c.updated[0].Properties["a"] != 1 || c.removed[0].Properties[0] != "b" {
+		t.Fatalf("changes share memory with their arguments: %v %v", c.updated, c.removed)
+	}
+}
+
+func TestMenuCommonAncestor(t *testing.T) {
+	// 0
+	// ├── 1
+	// │   ├── 2
+	// │   │   └── 3
+	// │   └── 4
+	// └── 5
+	menu := &Menu{nodes: map[int]*MenuItem{
+		1: {id: 1, parent: 0},
+		2: {id: 2, parent: 1},
+		3: {id: 3, parent: 2},
+		4: {id: 4, parent: 1},
+		5: {id: 5, parent: 0},
+	}}
+
+	tests := []struct {
+		ids  []int
+		want int
+	}{
+		{ids: nil, want: 0},
+		{ids: []int{3}, want: 3},
+		{ids: []int{3, 3}, want: 3},
+		{ids: []int{3, 2}, want: 2},
+		{ids: []int{3, 4}, want: 1},
+		{ids: []int{4, 3, 2}, want: 1},
+		{ids: []int{3, 5}, want: 0},
+		{ids: []int{0, 3}, want: 0},
+		{ids: []int{2, 6}, want: 2},
+		{ids: []int{6}, want: 0},
+	}
+
+	for _, test := range tests {
+		got := menu.commonAncestor(test.ids)
+		if got != test.want {
+			t.Errorf("commonAncestor(%v) = %v; want %v", test.ids, got, test.want)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name     string
+		f        func(tx *MenuTx, items map[string]*MenuItem) error
+		err      error
+		layout   string
+		signals  []string
+		revision uint32
+	}{
+		{
+			name: "Empty",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				return nil
+			},
+			layout: "a(b) c",
+		},
+		{
+			name: "Combined",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				d, err := tx.AddChild(nil, MenuItemLabel("d"))
+				if err != nil {
+					return err
+				}
+				_, err = tx.AddChild(d, MenuItemLabel("e"))
+				if err != nil {
+					return err
+				}
+				err = tx.MoveBefore(items["c"], items["a"])
+				if err != nil {
+					return err
+				}
+				return tx.SetProps(items["b"], MenuItemEnabled(false))
+			},
+			layout: "c a(b) d(e)",
+			signals: []string{
+				"layout root",
+				`props d.label="d" e.label="e" b.enabled=false`,
+			},
+			revision: 1,
+		},
+		{
+			name: "Nested",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				_, err := tx.AddChildBefore(items["b"], MenuItemLabel("x"))
+				if err != nil {
+					return err
+				}
+				_, err = tx.InsertChild(items["a"], 5, MenuItemLabel("y"))
+				return err
+			},
+			layout:   "a(x b y) c",
+			signals:  []string{"layout a", `props x.label="x" y.label="y"`},
+			revision: 1,
+		},
+		{
+			name: "UnbatchedCalls",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				if len(items["a"].Children()) != 1 {
+					return errors.New("wrong children")
+				}
+				err := items["b"].SetProps(MenuItemLabel("B"))
+				if err != nil {
+					return err
+				}
+				return tx.Remove(items["c"])
+			},
+			layout:   "a(B)",
+			signals:  []string{`props B.label="B"`, "layout a", "layout root"},
+			revision: 2,
+		},
+		{
+			name: "Error",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				tx.Remove(items["c"])
+				tx.AddChild(nil, MenuItemLabel("x"))
+				return errFailed
+			},
+			err:    errFailed,
+			layout: "a(b) c",
+		},
+		{
+			name: "ChangeFails",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				tx.Remove(items["b"])
+				tx.Remove(items["b"])
+				return tx.SetProps(items["c"], MenuItemLabel("C"))
+			},
+			err:      ErrDetached,
+			layout:   "a C",
+			signals:  []string{"layout root", `props C.label="C"`},
+			revision: 1,
+		},
+		{
+			name: "DifferentMenus",
+			f: func(tx *MenuTx, items map[string]*MenuItem) error {
+				other, _ := newTestItem(t)
+				x, _ := other.menu.AddChild()
+				return tx.Remove(x)
+			},
+			err:    ErrDifferentMenus,
+			layout: "a(b) c",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+			menu := item.menu
+
+			items := make(map[string]*MenuItem)
+			items["a"], _ = menu.AddChild(MenuItemLabel("a"))
+			items["b"], _ = items["a"].AddChild(MenuItemLabel("b"))
+			items["c"], _ = menu.AddChild(MenuItemLabel("c"))
+			bus.signals(t)
+			revision := menu.revision
+
+			done := make(chan error, 1)
+			go func() {
+				done <- menu.Batch(func(tx *MenuTx) error { return test.f(tx, items) })
+			}()
+			var err error
+			select {
+			case err = <-done:
+			case <-time.After(time.Second):
+				t.Fatal("Batch deadlocked")
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("error = %v; want %v", err, test.err)
+			}
+
+			if layout := renderMenu(menu, itemLabel); layout != test.layout {
+				t.Errorf("layout = %q; want %q", layout, test.layout)
+			}
+			if sigs := bus.menuSignals(t, menu, itemLabel); !slices.Equal(sigs, test.signals) {
+				t.Errorf("signals = %q; want %q", sigs, test.signals)
+			}
+			if n := menu.revision - revision; n != test.revision {
+				t.Errorf("revision was incremented %v times; want %v", n, test.revision)
+			}
+		})
+	}
+}
+
+func TestBatchDone(t *testing.T) {
+	item, _ := newTestItem(t)
+
+	var tx *MenuTx
+	err := item.menu.Batch(func(t *MenuTx) error {
+		tx = t
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tx.AddChild(nil)
+	if !errors.Is(err, ErrTxDone) {
+		t.Fatalf("AddChild after Batch returned %v", err)
+	}
+	if len(item.menu.Children()) != 0 {
+		t.Fatal("menu was changed after Batch returned")
+	}
+}
+
+func TestBatchPanic(t *testing.T) {
+	item, _ := newTestItem(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic did not continue")
+			}
+		}()
+		item.menu.Batch(func(tx *MenuTx) error {
+			tx.AddChild(nil)
+			panic("oops")
+		})
+	}()
+
+	if item.menu.batch != nil {
+		t.Fatal("batch was left in place")
+	}
+	_, err := item.menu.AddChild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(item.menu.Children()); n != 1 {
+		t.Fatalf("menu has %v children; want 1", n)
+	}
+}
@@ -0,0 +1,147 @@
+package tray
+
+import "sync"
+
+func toggleState(checked bool) MenuToggleState {
+	if checked {
+		return On
+	}
+	return Off
+}
+
+// checkbox serializes clicks on a self-managing checkbox item so that
+// each one sees the result of the previous one and change callbacks
+// are called in the same order as the state changes.
+type checkbox struct {
+	m    sync.Mutex
+	item *MenuItem
+}
+
+func (c *checkbox) click(next func(MenuToggleState) MenuToggleState, after func(bool)) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	state, err := c.item.setToggleState(next)
+	if err != nil {
+		return err
+	}
+	if after != nil {
+		after(state == On)
+	}
+	return nil
+}
+
+// setToggleState atomically updates the item's toggle state based on
+// its current one and emits the change.
+func (item *MenuItem) setToggleState(next func(MenuToggleState) MenuToggleState) (MenuToggleState, error) {
+	defer item.menu.lock()()
+	if err := item.checkAttached(); err != nil {
+		return 0, err
+	}
+	defer item.lock()()
+	if item.radio != nil {
+		return 0, ErrRadioMember
+	}
+
+	state := next(mapLookup(item.props, "toggle-state", MenuToggleState(-1)))
+	item.props["toggle-state"] = state
+	item.menu.dirty.mark(item.parent)
+
+	return state, item.menu.emitItemsPropertiesUpdated(
+		[]menuProps{{ID: item.id, Properties: map[string]any{"toggle-state": state}}},
+		nil,
+	)
+}
+
+// Checked returns true if the item's "toggle-state" property is [On].
+func (item *MenuItem) Checked() bool {
+	return item.ToggleState() == On
+}
+
+// SetChecked sets the item's "toggle-state" property to either [On]
+// or [Off]. Unlike SetProps, it only emits the single property change.
+func (item *MenuItem) SetChecked(checked bool) error {
+	_, err := item.setToggleState(func(MenuToggleState) MenuToggleState { return toggleState(checked) })
+	return err
+}
+
+// MenuItemCheckbox turns a MenuItem into a self-managing checkbox. It
+// sets the "toggle-type" property to [Checkmark], sets the
+// "toggle-state" property to state, and sets the item's event handler
+// to one that flips the toggle state whenever the item is clicked and
+// then calls onChange, if it is not nil, with the new state. If the
+// state is indeterminate when clicked, it becomes [On].
+//
+// The flip is atomic, so clicks that arrive in quick succession are
+// each applied to the result of the previous one.
+func MenuItemCheckbox(state MenuToggleState, onChange func(checked bool)) MenuItemProp {
+	return func(item *menuItemProps) {
+		c := checkbox{item: item.MenuItem}
+		setCheckbox(item, state, func(MenuEventID, any, uint32) error {
+			return c.click(
+				func(state MenuToggleState) MenuToggleState { return toggleState(state != On) },
+				onChange,
+			)
+		})
+	}
+}
+
+// MenuItemCheckboxBinding is like [MenuItemCheckbox] but binds the
+// state of the checkbox to an external value. The initial state is
+// determined by calling get. When clicked, the new state is the
+// opposite of what get returns, and set is called with it.
+//
+// If the external value changes by some other means, use
+// [MenuItem.SetChecked] to update the item.
+func MenuItemCheckboxBinding(get func() bool, set func(bool)) MenuItemProp {
+	return func(item *menuItemProps) {
+		c := checkbox{item: item.MenuItem}
+		setCheckbox(item, toggleState(get()), func(MenuEventID, any, uint32) error {
+			return c.click(
+				func(MenuToggleState) MenuToggleState { return toggleState(!get()) },
+				set,
+			)
+		})
+	}
+}
+
+func setCheckbox(item *menuItemProps, state MenuToggleState, onClick func(MenuEventID, any, uint32) error) {
+	if item.radio != nil {
+		item.catch(ErrRadioMember)
+		return
+	}
+
+	item.props["toggle-type"] = Checkmark
+	item.mark("toggle-type")
+	item.props["toggle-state"] = state
+	item.mark("toggle-state")
+	item.handler = func(eventID MenuEventID, data any, timestamp uint32) error {
+		if eventID != Clicked {
+			return nil
+		}
+		return onClick(eventID, data, timestamp)
+	}
+}
+
+// AddCheckbox is a convenience method that adds a new self-managing
+// checkbox item with the given label as the last child of the root of
+// the menu hierarchy. Any additional props are applied after the
+// checkbox is set up. See [MenuItemCheckbox].
+func (menu *Menu) AddCheckbox(label string, initial bool, onChange func(checked bool), props ...MenuItemProp) (*MenuItem, error) {
+	return menu.AddChild(checkboxProps(label, initial, onChange, props)...)
+}
+
+// AddCheckbox is a convenience method that adds a new self-managing
+// checkbox item with the given label as the last child of the item.
+// Any additional props are applied after the checkbox is set up. See
+// [MenuItemCheckbox].
+func (item *MenuItem) AddCheckbox(label string, initial bool, onChange func(checked bool), props ...MenuItemProp) (*MenuItem, error) {
+	return item.AddChild(checkboxProps(label, initial, onChange, props)...)
+}
+
+func checkboxProps(label string, initial bool, onChange func(bool), props []MenuItemProp) []MenuItemProp {
+	return append([]MenuItemProp{
+		MenuItemLabel(label),
+		MenuItemCheckbox(toggleState(initial), onChange),
+	}, props...)
+}
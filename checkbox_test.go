@@ -0,0 +1,134 @@
+package tray
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMenuItemCheckbox(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial MenuToggleState
+		clicks  int
+		state   MenuToggleState
+		changes []bool
+	}{
+		{name: "Unclicked", initial: On, state: On},
+		{name: "Off", initial: Off, clicks: 1, state: On, changes: []bool{true}},
+		{name: "On", initial: On, clicks: 1, state: Off, changes: []bool{false}},
+		{name: "Twice", initial: Off, clicks: 2, state: Off, changes: []bool{true, false}},
+		{name: "Indeterminate", initial: -1, clicks: 1, state: On, changes: []bool{true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+
+			var changes []bool
+			c, err := item.menu.AddChild(
+				MenuItemLabel("c"),
+				MenuItemCheckbox(test.initial, func(checked bool) { changes = append(changes, checked) }),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.ToggleType() != Checkmark {
+				t.Errorf("toggle type = %q", c.ToggleType())
+			}
+			bus.signals(t)
+
+			for range test.clicks {
+				err := click(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if c.ToggleState() != test.state {
+				t.Errorf("state = %v; want %v", c.ToggleState(), test.state)
+			}
+			if !slices.Equal(changes, test.changes) {
+				t.Errorf("changes = %v; want %v", changes, test.changes)
+			}
+			if sigs := bus.menuSignals(t, item.menu, itemLabel); len(sigs) != test.clicks {
+				t.Errorf("signals = %q; want one per click", sigs)
+			}
+		})
+	}
+}
+
+func TestMenuItemCheckboxBinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial bool
+		steps   []string
+		value   bool
+		state   MenuToggleState
+		err     error
+	}{
+		{name: "Initial", initial: true, value: true, state: On},
+		{name: "Click", initial: false, steps: []string{"click"}, value: true, state: On},
+		{name: "ClickTwice", initial: true, steps: []string{"click", "click"}, value: true, state: On},
+		{name: "ExternalChange", initial: false, steps: []string{"set true", "click"}, value: false, state: Off},
+		{name: "SetChecked", initial: false, steps: []string{"set true", "sync"}, value: true, state: On},
+		{name: "RadioMember", initial: true, steps: []string{"radio", "click"}, value: true, state: On, err: ErrRadioMember},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, _ := newTestItem(t)
+
+			value := test.initial
+			c, err := item.menu.AddChild(MenuItemCheckboxBinding(
+				func() bool { return value },
+				func(v bool) { value = v },
+			))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, step := range test.steps {
+				switch step {
+				case "click":
+					err = click(c)
+				case "set true":
+					value = true
+				case "sync":
+					err = c.SetChecked(value)
+				case "radio":
+					_, err = NewRadioGroup(c)
+				}
+				if err != nil && !errors.Is(err, test.err) {
+					t.Fatalf("%v: %v", step, err)
+				}
+			}
+
+			if value != test.value {
+				t.Errorf("value = %v; want %v", value, test.value)
+			}
+			if c.ToggleState() != test.state {
+				t.Errorf("state = %v; want %v", c.ToggleState(), test.state)
+			}
+			if c.Checked() != (test.state == On) {
+				t.Errorf("Checked() = %v", c.Checked())
+			}
+		})
+	}
+}
+
+func TestAddCheckbox(t *testing.T) {
+	item, _ := newTestItem(t)
+
+	parent, _ := item.menu.AddChild(MenuItemLabel("parent"))
+	c, err := parent.AddCheckbox("c", true, nil, MenuItemEnabled(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Label() != "c" || !c.Checked() || c.Enabled() {
+		t.Fatalf("unexpected properties %v", c.props)
+	}
+	if renderMenu(item.menu, itemLabel) != "parent(c)" {
+		t.Fatalf("layout = %q", renderMenu(item.menu, itemLabel))
+	}
+}
@@ -0,0 +1,97 @@
+package tray
+
+import (
+	"slices"
+	"sync"
+)
+
+// defaultFullPropsHosts are the hosts that are sent all properties by
+// default. The GNOME Shell AppIndicator extension seems to
+// occasionally fail to display items correctly if it is sent only the
+// properties that it asks for.
+var defaultFullPropsHosts = []string{"org.gnome.Shell"}
+
+// menuCompat holds per-host compatibility rules for the menu.
+type menuCompat struct {
+	m              sync.RWMutex
+	noFilter       bool
+	fullPropsHosts []string
+	fullProps      map[string]bool
+}
+
+// sendAllProperties determines whether or not sender should be sent
+// all properties regardless of which ones it asked for. Unique bus
+// names are never reused, so the result is cached per sender.
+func (menu *Menu) sendAllProperties(sender string) bool {
+	c := &menu.compat
+
+	c.m.RLock()
+	noFilter, hosts := c.noFilter, c.fullPropsHosts
+	full, ok := c.fullProps[sender]
+	c.m.RUnlock()
+
+	if noFilter {
+		return true
+	}
+	if ok {
+		return full
+	}
+
+	full = slices.ContainsFunc(hosts, func(name string) bool {
+		return menu.item.ownsName(sender, name)
+	})
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.fullProps == nil {
+		c.fullProps = make(map[string]bool)
+	}
+	c.fullProps[sender] = full
+	return full
+}
+
+// ownsName checks if the connection with the given unique name is
+// the current owner of name.
+func (item *Item) ownsName(sender, name string) bool {
+	if sender == name {
+		return true
+	}
+
+	var owner string
+	err := dbusCall(item.conn.BusObject(), "org.freedesktop.DBus.GetNameOwner", 0, name).Store(&owner)
+	return err == nil && owner == sender
+}
+
+// ItemMenuFilterProperties sets whether or not the menu honors the
+// list of property names that hosts ask for when they request the
+// layout or the properties of a group of items. If it is false, all
+// properties are always sent. The default is true.
+func ItemMenuFilterProperties(enabled bool) ItemProp {
+	return func(item *itemProps) {
+		c := &item.menu.compat
+
+		c.m.Lock()
+		defer c.m.Unlock()
+
+		c.noFilter = !enabled
+	}
+}
+
+// ItemMenuFullPropertiesHosts sets the bus names of hosts that are
+// always sent all properties, regardless of which ones they ask for,
+// as a workaround for hosts that don't handle being sent only the
+// ones that they ask for properly. Both unique and well-known names
+// can be given. A caller matches a well-known name if it currently
+// owns it. The default is "org.gnome.Shell".
+func ItemMenuFullPropertiesHosts(names ...string) ItemProp {
+	return func(item *itemProps) {
+		c := &item.menu.compat
+
+		c.m.Lock()
+		defer c.m.Unlock()
+
+		c.fullPropsHosts = slices.Clone(names)
+		c.fullProps = nil
+	}
+}
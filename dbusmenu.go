@@ -19,18 +19,66 @@ import (
 // The error returned by the handler is sent as a response to the
 // D-Bus call that triggered it, so in a lot of cases it is more
 // useful to handle an error locally in some way and then return nil
-// from the handler regardless.
+// from the handler regardless. When using [DispatchAsync], errors are
+// instead passed to the function set via [ItemErrorHandler]. To fail
+// the call with a specific D-Bus error name, return an [*Error].
 //
 // For the simple common case of handling specifically [Clicked]
-// events, see [ClickedHandler].
+// events, see [ClickedHandler]. To handle several kinds of events
+// separately, see [MenuEventHandlers]. For a handler that is given the
+// item that the event is for, see [MenuItemEventHandler].
 type MenuEventHandler func(eventID MenuEventID, data any, timestamp uint32) error
 
+// MenuItemEventHandler is like [MenuEventHandler] but is also given
+// the item that the event is for. This allows a single handler to be
+// shared between many items and avoids the need for a handler to
+// refer to the item via a variable that is only assigned after the
+// item has been created. The item's menu and tray item are available
+// via [MenuItem.Menu] and [Menu.Item]. See [MenuItemOnEvent].
+type MenuItemEventHandler func(item *MenuItem, eventID MenuEventID, data any, timestamp uint32) error
+
 // ClickedHandler is a convenience function that returns a
 // MenuEventHandler that calls handler if and only if the event ID is
 // [Clicked].
 func ClickedHandler(handler func(data any, timestamp uint32) error) MenuEventHandler {
-	return func(eventID MenuEventID, data any, timestamp uint32) error {
+	return eventIDHandler(Clicked, handler)
+}
+
+// HoveredHandler is a convenience function that returns a
+// MenuEventHandler that calls handler if and only if the event ID is
+// [Hovered].
+func HoveredHandler(handler func(data any, timestamp uint32) error) MenuEventHandler {
+	return eventIDHandler(Hovered, handler)
+}
+
+// OpenedHandler is a convenience function that returns a
+// MenuEventHandler that calls handler if and only if the event ID is
+// [Opened].
+func OpenedHandler(handler func(data any, timestamp uint32) error) MenuEventHandler {
+	return eventIDHandler(Opened, handler)
+}
+
+// ClosedHandler is a convenience function that returns a
+// MenuEventHandler that calls handler if and only if the event ID is
+// [Closed].
+func ClosedHandler(handler func(data any, timestamp uint32) error) MenuEventHandler {
+	return eventIDHandler(Closed, handler)
+}
+
+// ClickedItemHandler is like [ClickedHandler] but returns a
+// MenuItemEventHandler.
+func ClickedItemHandler(handler func(item *MenuItem, data any, timestamp uint32) error) MenuItemEventHandler {
+	return func(item *MenuItem, eventID MenuEventID, data any, timestamp uint32) error {
 		if eventID == Clicked {
+			return handler(item, data, timestamp)
+		}
+		return nil
+	}
+}
+
+func eventIDHandler(id MenuEventID, handler func(data any, timestamp uint32) error) MenuEventHandler {
+	return func(eventID MenuEventID, data any, timestamp uint32) error {
+		if eventID == id {
 			return handler(data, timestamp)
 		}
 		return nil
@@ -39,12 +87,12 @@ func ClickedHandler(handler func(data any, timestamp uint32) error) MenuEventHan
 
 type dbusmenu Menu
 
-func (menu *dbusmenu) buildLayout(item *MenuItem, depth int, props []string) menuLayout {
+func (menu *dbusmenu) buildLayout(sender string, item *MenuItem, depth int, props []string) menuLayout {
 	if item == nil {
 		return menuLayout{
 			ID:         0,
 			Properties: map[string]any{"children-display": "submenu"},
-			Children:   menu.buildChildren(item, depth, props),
+			Children:   menu.buildChildren(sender, item, depth, props),
 		}
 	}
 
@@ -53,12 +101,12 @@ func (menu *dbusmenu) buildLayout(item *MenuItem, depth int, props []string) men
 
 	return menuLayout{
 		ID:         item.id,
-		Properties: mapSlice(item.props, nil), // Workaround for possible bug in the GNOME extension.
-		Children:   menu.buildChildren(item, depth, props),
+		Properties: mapSlice(item.props, props),
+		Children:   menu.buildChildren(sender, item, depth, props),
 	}
 }
 
-func (menu *dbusmenu) buildChildren(parent *MenuItem, depth int, props []string) []any {
+func (menu *dbusmenu) buildChildren(sender string, parent *MenuItem, depth int, props []string) []any {
 	if depth == 0 { // -1 is infinite, so check is exactly to 0.
 		return nil
 	}
@@ -70,22 +118,26 @@ func (menu *dbusmenu) buildChildren(parent *MenuItem, depth int, props []string)
 		ids = parent.children
 	}
 
-	menu.dirty.Remove(parentID)
+	menu.dirty.markSeen(sender, parentID)
 
 	children := make([]any, 0, len(ids))
 	for _, id := range ids {
 		child := menu.nodes[id]
 		if child != nil {
-			children = append(children, menu.buildLayout(child, depth-1, props))
+			children = append(children, menu.buildLayout(sender, child, depth-1, props))
 		}
 	}
 
 	return children
 }
 
-func (menu *dbusmenu) GetLayout(parentID int, recursionDepth int, propertyNames []string) (revision uint32, layout menuLayout, derr *dbus.Error) {
+func (menu *dbusmenu) GetLayout(sender dbus.Sender, parentID int, recursionDepth int, propertyNames []string) (revision uint32, layout menuLayout, derr *dbus.Error) {
 	logger.Info("menu method", "name", "GetLayout", "parentID", parentID, "propertyNames", propertyNames)
 
+	if (*Menu)(menu).sendAllProperties(string(sender)) {
+		propertyNames = nil
+	}
+
 	// TODO: Find a way to do this without locking it.
 	menu.m.Lock()
 	defer menu.m.Unlock()
@@ -98,13 +150,17 @@ func (menu *dbusmenu) GetLayout(parentID int, recursionDepth int, propertyNames
 		}
 	}
 
-	layout = menu.buildLayout(parent, recursionDepth, propertyNames)
+	layout = menu.buildLayout(string(sender), parent, recursionDepth, propertyNames)
 	return menu.revision, layout, nil
 }
 
-func (menu *dbusmenu) GetGroupProperties(ids []int, propertyNames []string) ([]menuProps, *dbus.Error) {
+func (menu *dbusmenu) GetGroupProperties(sender dbus.Sender, ids []int, propertyNames []string) ([]menuProps, *dbus.Error) {
 	logger.Info("menu method", "name", "GetGroupProperties", "ids", ids, "propertyNames", propertyNames)
 
+	if (*Menu)(menu).sendAllProperties(string(sender)) {
+		propertyNames = nil
+	}
+
 	menu.m.RLock()
 	defer menu.m.RUnlock()
 
@@ -125,7 +181,7 @@ func (menu *dbusmenu) GetGroupProperties(ids []int, propertyNames []string) ([]m
 		item.m.RLock()
 		r = append(r, menuProps{
 			ID:         item.id,
-			Properties: mapSlice(item.props, nil), // Workaround for possible bug in the GNOME extension.
+			Properties: mapSlice(item.props, propertyNames),
 		})
 		item.m.RUnlock()
 	}
@@ -154,47 +210,89 @@ func (menu *dbusmenu) GetProperty(id int, name string) (any, *dbus.Error) {
 	return v, nil
 }
 
-func (menu *dbusmenu) getHandler(id int) MenuEventHandler {
+func (menu *dbusmenu) getHandler(id int) (*MenuItem, MenuEventHandler, *RadioGroup) {
 	menu.m.RLock()
 	defer menu.m.RUnlock()
 
 	if id == 0 {
-		return menu.handler
+		return nil, menu.handler, nil
 	}
 
 	item := menu.nodes[id]
 	if item == nil {
-		return nil
+		return nil, nil, nil
 	}
 
 	item.m.RLock()
 	defer item.m.RUnlock()
 
-	return item.handler
+	return item, item.handler, item.radio
 }
 
 func (menu *dbusmenu) event(id int, eventID MenuEventID, data dbus.Variant, timestamp uint32) error {
-	h := menu.getHandler(id)
-	if h == nil {
-		return nil
-	}
+	return menu.item.dispatch(func() error {
+		// The about-to-show hook runs as part of the dispatched event so
+		// that it stays in order with the events around it.
+		if eventID == Opened {
+			_, err := menu.aboutToShow(id)
+			if err != nil {
+				return err
+			}
+		}
+
+		item, h, radio := menu.getHandler(id)
+		if item == nil && id != 0 {
+			return nil
+		}
+
+		ev := MenuEvent{
+			Item:      item,
+			ID:        eventID,
+			Data:      data.Value(),
+			Timestamp: timestamp,
+		}
+		menu.item.emitEvent(ev)
 
-	return h(eventID, data.Value(), timestamp)
+		selects := radio != nil && eventID == Clicked
+		if h == nil && !selects {
+			return nil
+		}
+		return menu.item.invoke(ev, func() error {
+			var err error
+			if selects {
+				err = radio.Select(item)
+			}
+			if h != nil {
+				err = errors.Join(err, h(eventID, ev.Data, timestamp))
+			}
+			return err
+		})
+	})
 }
 
-func (menu *dbusmenu) Event(id int, eventID MenuEventID, data dbus.Variant, timestamp uint32) *dbus.Error {
+func (menu *dbusmenu) Event(sender dbus.Sender, id int, eventID MenuEventID, data dbus.Variant, timestamp uint32) *dbus.Error {
 	logger.Info("menu method", "name", "Event", "id", id, "eventID", eventID, "data", data, "timestamp", timestamp)
 
+	derr := menu.item.checkAccess(sender, "Event")
+	if derr != nil {
+		return derr
+	}
+
 	err := menu.event(id, eventID, data, timestamp)
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return makeDBusError(err)
 	}
 	return nil
 }
 
-func (menu *dbusmenu) EventGroup(events []menuEvent) ([]int, *dbus.Error) {
+func (menu *dbusmenu) EventGroup(sender dbus.Sender, events []menuEvent) ([]int, *dbus.Error) {
 	logger.Info("menu method", "name", "EventGroup", "events", events)
 
+	derr := menu.item.checkAccess(sender, "EventGroup")
+	if derr != nil {
+		return nil, derr
+	}
+
 	ids := make([]int, 0, len(events))
 	errs := make([]error, 0, len(events))
 	for _, event := range events {
@@ -207,24 +305,60 @@ func (menu *dbusmenu) EventGroup(events []menuEvent) ([]int, *dbus.Error) {
 
 	err := errors.Join(errs...)
 	if err != nil {
-		return ids, dbus.MakeFailedError(err)
+		return ids, makeDBusError(err)
 	}
 
 	return ids, nil
 }
 
-func (menu *dbusmenu) AboutToShow(id int) (bool, *dbus.Error) {
+// aboutToShow calls the AboutToShow hook of the item with the given
+// ID, if it has one, and reports whether or not there was one to
+// call.
+func (menu *dbusmenu) aboutToShow(id int) (bool, error) {
+	menu.m.RLock()
+	item := menu.nodes[id]
+	menu.m.RUnlock()
+	if item == nil {
+		return false, nil
+	}
+
+	item.m.RLock()
+	hook := item.aboutToShow
+	item.m.RUnlock()
+	if hook == nil {
+		return false, nil
+	}
+
+	ev := AboutToShowEvent{Item: item}
+	menu.item.emitEvent(ev)
+	return true, menu.item.invoke(ev, func() error { return hook(item) })
+}
+
+func (menu *dbusmenu) AboutToShow(sender dbus.Sender, id int) (bool, *dbus.Error) {
 	logger.Info("menu method", "name", "AboutToShow", "id", id)
 
+	ran, err := menu.aboutToShow(id)
+	if err != nil {
+		return false, makeDBusError(err)
+	}
+
 	menu.m.RLock()
 	defer menu.m.RUnlock()
 
-	return menu.dirty.Contains(id), nil
+	return ran || menu.dirty.isDirty(string(sender), id), nil
 }
 
-func (menu *dbusmenu) AboutToShowGroup(ids []int) (updates []menuUpdate, invalid []int, derr *dbus.Error) {
+func (menu *dbusmenu) AboutToShowGroup(sender dbus.Sender, ids []int) (updates []menuUpdate, invalid []int, derr *dbus.Error) {
 	logger.Info("menu method", "name", "AboutToShowGroup", "ids", ids)
 
+	ran := make(map[int]bool, len(ids))
+	errs := make([]error, 0, len(ids))
+	for _, id := range ids {
+		r, err := menu.aboutToShow(id)
+		ran[id] = r
+		errs = append(errs, err)
+	}
+
 	menu.m.RLock()
 	defer menu.m.RUnlock()
 
@@ -237,10 +371,15 @@ func (menu *dbusmenu) AboutToShowGroup(ids []int) (updates []menuUpdate, invalid
 
 		updates = append(updates, menuUpdate{
 			ID:         id,
-			NeedUpdate: menu.dirty.Contains(id),
+			NeedUpdate: ran[id] || menu.dirty.isDirty(string(sender), id),
 		})
 	}
 
+	err := errors.Join(errs...)
+	if err != nil {
+		return updates, invalid, makeDBusError(err)
+	}
+
 	return updates, invalid, nil
 }
 
@@ -304,6 +443,11 @@ type menuProps struct {
 	Properties map[string]any
 }
 
+type menuRemovedProps struct {
+	ID         int
+	Properties []string
+}
+
 type menuEvent struct {
 	ID        int
 	EventID   MenuEventID
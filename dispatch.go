@@ -0,0 +1,136 @@
+package tray
+
+import "sync"
+
+// DispatchMode determines how incoming events are delivered to
+// handlers.
+type DispatchMode int
+
+const (
+	// DispatchSync calls handlers directly from the goroutine that
+	// received the D-Bus method call. The reply to the call is not sent
+	// until the handler returns, and any error that it returns is sent
+	// as part of the reply. While a handler is running, no other
+	// incoming calls to the item or its menu are processed by it.
+	DispatchSync DispatchMode = iota
+
+	// DispatchAsync queues events onto a dedicated worker goroutine
+	// that calls handlers one at a time in the order that the events
+	// arrived. D-Bus calls are replied to immediately without waiting
+	// for the handler, so errors returned by handlers are instead
+	// reported to the function set via [ItemErrorHandler].
+	DispatchAsync
+)
+
+func (mode DispatchMode) String() string {
+	switch mode {
+	case DispatchSync:
+		return "sync"
+	case DispatchAsync:
+		return "async"
+	default:
+		return "unknown"
+	}
+}
+
+// eventQueue is an unbounded FIFO queue of functions that are run one
+// at a time on a worker goroutine. The worker is only running while
+// there are functions in the queue.
+type eventQueue struct {
+	m       sync.Mutex
+	queue   []func()
+	running bool
+}
+
+func (q *eventQueue) push(f func()) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	q.queue = append(q.queue, f)
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+func (q *eventQueue) pop() (func(), bool) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if len(q.queue) == 0 {
+		q.running = false
+		q.queue = nil
+		return nil, false
+	}
+
+	f := q.queue[0]
+	q.queue[0] = nil
+	q.queue = q.queue[1:]
+	return f, true
+}
+
+func (q *eventQueue) run() {
+	for {
+		f, ok := q.pop()
+		if !ok {
+			return
+		}
+		f()
+	}
+}
+
+// dispatch calls f according to the item's current DispatchMode. In
+// synchronous mode, the error returned by f is returned directly. In
+// asynchronous mode, f is queued, dispatch returns nil immediately,
+// and the error is reported to the item's error handler instead.
+func (item *Item) dispatch(f func() error) error {
+	if DispatchMode(item.dispatchMode.Load()) != DispatchAsync {
+		return f()
+	}
+
+	item.queue.push(func() {
+		err := f()
+		if err != nil {
+			item.reportError(err)
+		}
+	})
+	return nil
+}
+
+func (item *Item) reportError(err error) {
+	logger.Warn("handler failed", "err", err)
+
+	h := item.errorHandler.Load()
+	if h == nil {
+		return
+	}
+	(*h)(err)
+}
+
+// DispatchMode returns the mode that is currently being used to
+// deliver events to handlers.
+func (item *Item) DispatchMode() DispatchMode {
+	return DispatchMode(item.dispatchMode.Load())
+}
+
+// ItemDispatchMode sets the way that events are delivered to the
+// Item's [Handler] as well as to the [MenuEventHandler] functions of
+// its menu. The default is [DispatchSync].
+func ItemDispatchMode(mode DispatchMode) ItemProp {
+	return func(item *itemProps) {
+		item.dispatchMode.Store(int32(mode))
+	}
+}
+
+// ItemErrorHandler sets a function that is called with errors returned
+// by handlers that can not be reported as the response to a D-Bus
+// call, such as when using [DispatchAsync].
+func ItemErrorHandler(handler func(error)) ItemProp {
+	return func(item *itemProps) {
+		p := &handler
+		if handler == nil {
+			p = nil
+		}
+		item.errorHandler.Store(p)
+	}
+}
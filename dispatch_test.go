@@ -0,0 +1,142 @@
+package tray
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatchErrors(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name     string
+		mode     DispatchMode
+		err      error
+		returned error
+		reported error
+	}{
+		{name: "Sync", mode: DispatchSync},
+		{name: "SyncError", mode: DispatchSync, err: errHandler, returned: errHandler},
+		{name: "Async", mode: DispatchAsync},
+		{name: "AsyncError", mode: DispatchAsync, err: errHandler, reported: errHandler},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reported := make(chan error, 1)
+			item := &Item{}
+			item.SetProps(
+				ItemDispatchMode(test.mode),
+				ItemErrorHandler(func(err error) { reported <- err }),
+			)
+			if got := item.DispatchMode(); got != test.mode {
+				t.Fatalf("DispatchMode() = %v; want %v", got, test.mode)
+			}
+
+			ran := make(chan struct{})
+			err := item.dispatch(func() error {
+				close(ran)
+				return test.err
+			})
+			if err != test.returned {
+				t.Errorf("dispatch returned %v; want %v", err, test.returned)
+			}
+
+			select {
+			case <-ran:
+			case <-time.After(time.Second):
+				t.Fatal("function was never called")
+			}
+
+			if test.reported == nil {
+				select {
+				case err := <-reported:
+					t.Errorf("unexpected error reported: %v", err)
+				case <-time.After(10 * time.Millisecond):
+				}
+				return
+			}
+			select {
+			case err := <-reported:
+				if err != test.reported {
+					t.Errorf("reported %v; want %v", err, test.reported)
+				}
+			case <-time.After(time.Second):
+				t.Errorf("error was never reported")
+			}
+		})
+	}
+}
+
+func TestDispatchOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		mode DispatchMode
+	}{
+		{name: "Sync", mode: DispatchSync},
+		{name: "Async", mode: DispatchAsync},
+	}
+
+	const n = 100
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item := &Item{}
+			item.SetProps(ItemDispatchMode(test.mode))
+
+			var m sync.Mutex
+			var got []int
+			done := make(chan struct{})
+			for i := range n {
+				item.dispatch(func() error {
+					m.Lock()
+					defer m.Unlock()
+					got = append(got, i)
+					if i == n-1 {
+						close(done)
+					}
+					return nil
+				})
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("not all functions were called")
+			}
+
+			m.Lock()
+			defer m.Unlock()
+			want := make([]int, n)
+			for i := range want {
+				want[i] = i
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("functions were called in the order %v", got)
+			}
+		})
+	}
+}
+
+func TestEventQueueStops(t *testing.T) {
+	var q eventQueue
+	done := make(chan struct{})
+	q.push(func() { close(done) })
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		q.m.Lock()
+		running := q.running
+		q.m.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker is still running after the queue was emptied")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,127 @@
+package tray
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type menuDump struct {
+	Revision uint32         `json:"revision"`
+	DirtyFor []string       `json:"dirtyFor,omitempty"`
+	Children []menuItemDump `json:"children"`
+}
+
+type menuItemDump struct {
+	ID         int                     `json:"id"`
+	Key        string                  `json:"key,omitempty"`
+	DirtyFor   []string                `json:"dirtyFor,omitempty"`
+	Properties map[string]propertyDump `json:"properties"`
+	Children   []menuItemDump          `json:"children"`
+}
+
+type propertyDump struct {
+	Signature string `json:"signature"`
+	Value     any    `json:"value"`
+}
+
+// dump takes a snapshot of the current state of the menu.
+func (menu *Menu) dump() menuDump {
+	menu.m.RLock()
+	defer menu.m.RUnlock()
+
+	return menuDump{
+		Revision: menu.revision,
+		DirtyFor: menu.dirty.dirtyFor(0),
+		Children: menu.dumpChildren(menu.children),
+	}
+}
+
+func (menu *Menu) dumpChildren(ids []int) []menuItemDump {
+	items := make([]menuItemDump, 0, len(ids))
+	for _, item := range menu.snapshot(ids, false) {
+		item.m.RLock()
+		d := menuItemDump{
+			ID:         item.id,
+			Key:        item.key,
+			DirtyFor:   menu.dirty.dirtyFor(item.id),
+			Properties: make(map[string]propertyDump, len(item.props)),
+		}
+		for k, v := range item.props {
+			d.Properties[k] = propertyDump{
+				Signature: dbus.SignatureOf(v).String(),
+				Value:     v,
+			}
+		}
+		children := item.children
+		item.m.RUnlock()
+
+		d.Children = menu.dumpChildren(children)
+		items = append(items, d)
+	}
+	return items
+}
+
+// MarshalJSON implements [json.Marshaler] by encoding a snapshot of
+// the menu hierarchy, including the current layout revision, the
+// callers for which each item has changed since they last requested
+// its layout, and every property of every item along with its D-Bus
+// type signature. It is intended for debugging.
+func (menu *Menu) MarshalJSON() ([]byte, error) {
+	return json.Marshal(menu.dump())
+}
+
+// Dump writes a human-readable, indented representation of the same
+// information as [Menu.MarshalJSON] to w. It is intended for
+// debugging.
+func (menu *Menu) Dump(w io.Writer) error {
+	d := menu.dump()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "revision %v\n", d.Revision)
+	fmt.Fprintf(bw, "[0] root%v\n", dirtyFlag(d.DirtyFor))
+	dumpItems(bw, d.Children, 1)
+	return bw.Flush()
+}
+
+func dumpItems(w io.Writer, items []menuItemDump, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, item := range items {
+		fmt.Fprintf(w, "%v[%v]", indent, item.ID)
+		if item.Key != "" {
+			fmt.Fprintf(w, " key=%q", item.Key)
+		}
+		fmt.Fprintf(w, "%v\n", dirtyFlag(item.DirtyFor))
+
+		for _, name := range slices.Sorted(maps.Keys(item.Properties)) {
+			p := item.Properties[name]
+			fmt.Fprintf(w, "%v  %v (%v) = %v\n", indent, name, p.Signature, formatDumpValue(p.Value))
+		}
+
+		dumpItems(w, item.Children, depth+1)
+	}
+}
+
+func dirtyFlag(dirtyFor []string) string {
+	if len(dirtyFor) == 0 {
+		return ""
+	}
+	return " (dirty for " + strings.Join(dirtyFor, ", ") + ")"
+}
+
+func formatDumpValue(v any) string {
+	if data, ok := v.([]byte); ok {
+		return fmt.Sprintf("<%v bytes>", len(data))
+	}
+	if reflect.ValueOf(v).Kind() == reflect.String {
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprintf("%v", v)
+}
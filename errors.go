@@ -0,0 +1,103 @@
+package tray
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Commonly used D-Bus error names. These can be used with [NewError]
+// to control how an error returned by a handler is reported to the
+// caller.
+const (
+	ErrorFailed       = "org.freedesktop.DBus.Error.Failed"
+	ErrorAccessDenied = "org.freedesktop.DBus.Error.AccessDenied"
+	ErrorInvalidArgs  = "org.freedesktop.DBus.Error.InvalidArgs"
+	ErrorNotSupported = "org.freedesktop.DBus.Error.NotSupported"
+)
+
+// Error is an error with a specific D-Bus error name. When a handler
+// returns an error that is or wraps an *Error, the D-Bus call that
+// triggered the handler fails with Name instead of the generic
+// org.freedesktop.DBus.Error.Failed.
+//
+// Errors that implement the [dbus.DBusError] interface are treated
+// the same way, as are a [dbus.Error] or *dbus.Error, which are
+// returned as is.
+type Error struct {
+	Name string
+	Err  error
+}
+
+// NewError returns an *Error with the given D-Bus error name that
+// wraps err.
+func NewError(name string, err error) error {
+	return &Error{Name: name, Err: err}
+}
+
+func (err *Error) Error() string {
+	if err.Err == nil {
+		return err.Name
+	}
+	return fmt.Sprintf("%v: %v", err.Name, err.Err)
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// DBusError implements [dbus.DBusError].
+func (err *Error) DBusError() (string, []any) {
+	if err.Err == nil {
+		return err.Name, nil
+	}
+	return err.Name, []any{err.Err.Error()}
+}
+
+// PanicError is the error that a recovered panic in a handler is
+// converted into. See [ItemRecoverPanics].
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("panic in handler: %v", err.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (err *PanicError) Unwrap() error {
+	e, _ := err.Value.(error)
+	return e
+}
+
+// DBusError implements [dbus.DBusError]. Panics are always reported
+// as [ErrorFailed], regardless of the panic value.
+func (err *PanicError) DBusError() (string, []any) {
+	return ErrorFailed, []any{err.Error()}
+}
+
+func makeDBusError(err error) *dbus.Error {
+	if err == nil {
+		return nil
+	}
+
+	var derr dbus.DBusError
+	if errors.As(err, &derr) {
+		name, body := derr.DBusError()
+		return dbus.NewError(name, body)
+	}
+
+	var perr *dbus.Error
+	if errors.As(err, &perr) && perr != nil {
+		return perr
+	}
+
+	var verr dbus.Error
+	if errors.As(err, &verr) {
+		return &verr
+	}
+
+	return dbus.MakeFailedError(err)
+}
@@ -0,0 +1,203 @@
+package tray
+
+import "sync"
+
+// Event is an event delivered via the channel returned by
+// [Item.Events]. The concrete type of an Event is one of the event
+// types defined in this package, such as [ActivateEvent] or
+// [MenuEvent].
+type Event interface {
+	isEvent()
+}
+
+// ContextMenuEvent is sent when the environment asks the item to show
+// a context menu at the given screen coordinates. It corresponds to
+// [Handler.ContextMenu].
+type ContextMenuEvent struct {
+	X, Y int
+}
+
+// ActivateEvent is sent when the item is activated, usually by being
+// clicked. It corresponds to [Handler.Activate].
+type ActivateEvent struct {
+	X, Y int
+}
+
+// SecondaryActivateEvent is sent when the item is activated in a
+// secondary way, such as by a middle click. It corresponds to
+// [Handler.SecondaryActivate].
+type SecondaryActivateEvent struct {
+	X, Y int
+}
+
+// ScrollEvent is sent when the user scrolls over the item. It
+// corresponds to [Handler.Scroll].
+type ScrollEvent struct {
+	Delta       int
+	Orientation Orientation
+}
+
+// MenuEvent is sent when the environment sends an event to the menu
+// or to one of its items. Item is nil if the event was sent to the
+// root of the menu. It corresponds to [MenuEventHandler].
+type MenuEvent struct {
+	Item      *MenuItem
+	ID        MenuEventID
+	Data      any
+	Timestamp uint32
+}
+
+// AboutToShowEvent is sent when the environment is about to show the
+// children of an item that has a hook set via [MenuItemOnAboutToShow],
+// just before the hook is called.
+type AboutToShowEvent struct {
+	Item *MenuItem
+}
+
+// RegisteredEvent is sent when the item has been successfully
+// registered with a StatusNotifierWatcher. Watcher is the bus name of
+// the watcher that the item was registered with.
+type RegisteredEvent struct {
+	Watcher string
+}
+
+func (ContextMenuEvent) isEvent()       {}
+func (ActivateEvent) isEvent()          {}
+func (SecondaryActivateEvent) isEvent() {}
+func (ScrollEvent) isEvent()            {}
+func (MenuEvent) isEvent()              {}
+func (AboutToShowEvent) isEvent()       {}
+func (RegisteredEvent) isEvent()        {}
+
+// EventPolicy determines what happens when an event is sent while the
+// channel returned by [Item.Events] is full.
+type EventPolicy int
+
+const (
+	// EventBlock waits until there is room in the channel. Note that
+	// unless [DispatchAsync] is being used, this blocks the processing
+	// of all further incoming D-Bus calls until the event is received.
+	EventBlock EventPolicy = iota
+
+	// EventDrop discards the event.
+	EventDrop
+)
+
+const defaultEventBuffer = 16
+
+type eventStream struct {
+	m      sync.RWMutex
+	ch     chan Event
+	policy EventPolicy
+	done   chan struct{}
+	once   sync.Once
+	closed bool
+}
+
+func newEventStream(buffer int, policy EventPolicy) *eventStream {
+	return &eventStream{
+		ch:     make(chan Event, max(buffer, 0)),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *eventStream) send(ev Event) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	if s.policy == EventDrop {
+		select {
+		case s.ch <- ev:
+		default:
+			logger.Warn("event dropped", "event", ev)
+		}
+		return
+	}
+
+	select {
+	case s.ch <- ev:
+	case <-s.done:
+	}
+}
+
+func (s *eventStream) close() {
+	s.once.Do(func() {
+		// Closing done first unblocks any senders that are waiting so
+		// that the write lock can be acquired.
+		close(s.done)
+
+		s.m.Lock()
+		defer s.m.Unlock()
+
+		s.closed = true
+		close(s.ch)
+	})
+}
+
+// Events returns a channel that receives an [Event] for every
+// incoming event that the item and its menu receive. Events are sent
+// in addition to, and before, calling any handlers that have been
+// set.
+//
+// By default, the channel has a buffer size of 16 and uses the
+// [EventBlock] policy. To change this, use [ItemEvents]. Events that
+// happen before the first call to either of those are not sent. The
+// channel is closed when the item is closed. If the item has already
+// been closed, the returned channel is closed, too.
+func (item *Item) Events() <-chan Event {
+	item.eventsM.Lock()
+	defer item.eventsM.Unlock()
+
+	if item.events == nil {
+		item.events = newEventStream(defaultEventBuffer, EventBlock)
+		if item.eventsClosed {
+			item.events.close()
+		}
+	}
+	return item.events.ch
+}
+
+func (item *Item) emitEvent(ev Event) {
+	item.eventsM.Lock()
+	events := item.events
+	item.eventsM.Unlock()
+
+	if events != nil {
+		events.send(ev)
+	}
+}
+
+func (item *Item) closeEvents() {
+	item.eventsM.Lock()
+	defer item.eventsM.Unlock()
+
+	item.eventsClosed = true
+	if item.events != nil {
+		item.events.close()
+	}
+}
+
+// ItemEvents configures the channel returned by [Item.Events] with
+// the given buffer size and policy. To receive a [RegisteredEvent]
+// for the initial registration of the item, this must be passed to
+// [New]. It has no effect if the channel has already been created.
+func ItemEvents(buffer int, policy EventPolicy) ItemProp {
+	return func(item *itemProps) {
+		item.eventsM.Lock()
+		defer item.eventsM.Unlock()
+
+		if item.events != nil {
+			logger.Warn("event channel already created", "buffer", buffer, "policy", policy)
+			return
+		}
+		item.events = newEventStream(buffer, policy)
+		if item.eventsClosed {
+			item.events.close()
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package tray
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventStreamPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  EventPolicy
+		buffer  int
+		sent    int
+		want    int
+		blocked bool
+	}{
+		{name: "BlockFits", policy: EventBlock, buffer: 2, sent: 2, want: 2},
+		{name: "BlockFull", policy: EventBlock, buffer: 2, sent: 3, want: 3, blocked: true},
+		{name: "BlockUnbuffered", policy: EventBlock, buffer: 0, sent: 1, want: 1, blocked: true},
+		{name: "DropFits", policy: EventDrop, buffer: 2, sent: 2, want: 2},
+		{name: "DropFull", policy: EventDrop, buffer: 2, sent: 5, want: 2},
+		{name: "DropUnbuffered", policy: EventDrop, buffer: 0, sent: 3, want: 0},
+		{name: "NegativeBuffer", policy: EventDrop, buffer: -1, sent: 1, want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newEventStream(test.buffer, test.policy)
+			defer s.close()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := range test.sent {
+					s.send(ScrollEvent{Delta: i})
+				}
+			}()
+
+			select {
+			case <-done:
+				if test.blocked {
+					t.Fatal("sending did not block on a full channel")
+				}
+			case <-time.After(20 * time.Millisecond):
+				if !test.blocked {
+					t.Fatal("sending blocked")
+				}
+			}
+
+			for i := range test.want {
+				select {
+				case ev := <-s.ch:
+					if ev != (ScrollEvent{Delta: i}) {
+						t.Fatalf("event %v = %#v", i, ev)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("event %v was never received", i)
+				}
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("sending still blocked after the events were received")
+			}
+			select {
+			case ev := <-s.ch:
+				t.Fatalf("unexpected event %#v", ev)
+			default:
+			}
+		})
+	}
+}
+
+func TestEventStreamClose(t *testing.T) {
+	s := newEventStream(0, EventBlock)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.send(ActivateEvent{})
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	s.close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("closing did not unblock the sender")
+	}
+
+	s.send(ActivateEvent{})
+	s.close()
+	if _, ok := <-s.ch; ok {
+		t.Fatal("channel is not closed")
+	}
+}
+
+func TestItemEvents(t *testing.T) {
+	var item Item
+	item.emitEvent(ActivateEvent{X: 1})
+
+	item.SetProps(ItemEvents(4, EventDrop))
+	item.SetProps(ItemEvents(8, EventBlock))
+	events := item.Events()
+	if events != item.Events() {
+		t.Fatal("Events returned a different channel")
+	}
+	if cap(events) != 4 || item.events.policy != EventDrop {
+		t.Fatalf("later ItemEvents was not ignored")
+	}
+
+	item.emitEvent(ActivateEvent{X: 2})
+	item.closeEvents()
+
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 1 || got[0] != (ActivateEvent{X: 2}) {
+		t.Fatalf("received %#v", got)
+	}
+}
+
+func TestEventsAfterClose(t *testing.T) {
+	tests := []struct {
+		name  string
+		props []ItemProp
+	}{
+		{name: "Default"},
+		{name: "ItemEvents", props: []ItemProp{ItemEvents(4, EventDrop)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var item Item
+			item.closeEvents()
+			item.SetProps(test.props...)
+
+			select {
+			case _, ok := <-item.Events():
+				if ok {
+					t.Fatal("received an event")
+				}
+			case <-time.After(time.Second):
+				t.Fatal("channel was not closed")
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"image"
 	_ "image/png"
-	"sync"
 	"time"
 
 	"deedles.dev/tray"
@@ -32,6 +31,34 @@ func onTrayActivate(x, y int) error {
 	return nil
 }
 
+var printProps = []tray.MenuItemProp{
+	tray.MenuItemLabel("Print"),
+	tray.MenuItemHandler(tray.ClickedHandler(func(data any, timestamp uint32) error {
+		fmt.Println("Print clicked.")
+		return nil
+	})),
+}
+
+// onEdit handles clicks on both the Add and Remove items, adding or
+// removing the Print item and then enabling whichever of the two
+// wasn't clicked.
+func onEdit(self *tray.MenuItem, data any, timestamp uint32) error {
+	menu := self.Menu()
+	switch self.Label() {
+	case "Add":
+		menu.InsertChild(2, printProps...)
+	case "Remove":
+		if p := menu.FindByLabel("Print"); p != nil {
+			p.Remove()
+		}
+	}
+
+	for _, sibling := range self.Parent().Children() {
+		sibling.SetProps(tray.MenuItemEnabled(sibling != self))
+	}
+	return nil
+}
+
 func main() {
 	done := make(chan struct{})
 
@@ -52,51 +79,21 @@ func main() {
 		tray.MenuItemLabel("Edit"),
 	)
 
-	var m sync.Mutex
-	var p, add, remove, quit *tray.MenuItem
-	props := []tray.MenuItemProp{
-		tray.MenuItemLabel("Print"),
-		tray.MenuItemHandler(tray.ClickedHandler(func(data any, timestamp uint32) error {
-			fmt.Println("Print clicked.")
-			return nil
-		})),
-	}
-
-	add, _ = group.AddChild(
+	group.AddChild(
 		tray.MenuItemLabel("Add"),
 		tray.MenuItemEnabled(false),
-		tray.MenuItemHandler(tray.ClickedHandler(func(data any, timestamp uint32) error {
-			m.Lock()
-			defer m.Unlock()
-
-			p, _ = item.Menu().AddChild(props...)
-			p.MoveBefore(quit)
-			add.SetProps(tray.MenuItemEnabled(false))
-			remove.SetProps(tray.MenuItemEnabled(true))
-			return nil
-		})),
+		tray.MenuItemOnEvent(tray.ClickedItemHandler(onEdit)),
 	)
-
-	remove, _ = group.AddChild(
+	group.AddChild(
 		tray.MenuItemLabel("Remove"),
-		tray.MenuItemHandler(tray.ClickedHandler(func(data any, timestamp uint32) error {
-			m.Lock()
-			defer m.Unlock()
-
-			p.Remove()
-			add.SetProps(tray.MenuItemEnabled(true))
-			remove.SetProps(tray.MenuItemEnabled(false))
-			return nil
-		})),
+		tray.MenuItemOnEvent(tray.ClickedItemHandler(onEdit)),
 	)
 
 	item.Menu().AddChild(tray.MenuItemType(tray.Separator))
 
-	m.Lock()
+	item.Menu().AddChild(printProps...)
 
-	p, _ = item.Menu().AddChild(props...)
-
-	quit, _ = item.Menu().AddChild(
+	quit, _ := item.Menu().AddChild(
 		tray.MenuItemLabel("Quit"),
 		tray.MenuItemHandler(tray.ClickedHandler(func(data any, timestamp uint32) error {
 			close(done)
@@ -104,12 +101,7 @@ func main() {
 		})),
 	)
 
-	m.Unlock()
-
 	time.AfterFunc(5*time.Second, func() {
-		m.Lock()
-		defer m.Unlock()
-
 		quit.SetProps(tray.MenuItemLabel("Exit"))
 	})
 
@@ -0,0 +1,63 @@
+package tray
+
+import (
+	"maps"
+	"slices"
+)
+
+// dirtyTracker keeps track of which nodes of the menu have changed
+// since each caller last requested their layouts. Changes are
+// numbered with a serial rather than the layout revision because
+// property changes also make a node's parent dirty without changing
+// the revision. It is protected by the menu's lock.
+type dirtyTracker struct {
+	serial  uint64
+	changed map[int]uint64
+	seen    map[string]map[int]uint64
+}
+
+// mark records that the node with the given ID has changed.
+func (t *dirtyTracker) mark(id int) {
+	if t.changed == nil {
+		t.changed = make(map[int]uint64)
+	}
+	t.serial++
+	t.changed[id] = t.serial
+}
+
+// markSeen records that sender has been sent the current layout of
+// the node with the given ID.
+func (t *dirtyTracker) markSeen(sender string, id int) {
+	if t.seen == nil {
+		t.seen = make(map[string]map[int]uint64)
+	}
+	seen := t.seen[sender]
+	if seen == nil {
+		seen = make(map[int]uint64)
+		t.seen[sender] = seen
+	}
+	seen[id] = t.serial
+}
+
+// isDirty reports whether the node with the given ID has changed since
+// sender was last sent its layout.
+func (t *dirtyTracker) isDirty(sender string, id int) bool {
+	return t.changed[id] > t.seen[sender][id]
+}
+
+// dirtyFor returns the callers that have requested the layout of the
+// menu and for which the node with the given ID is dirty.
+func (t *dirtyTracker) dirtyFor(id int) []string {
+	var senders []string
+	for _, sender := range slices.Sorted(maps.Keys(t.seen)) {
+		if t.isDirty(sender, id) {
+			senders = append(senders, sender)
+		}
+	}
+	return senders
+}
+
+// forget discards everything known about sender.
+func (t *dirtyTracker) forget(sender string) {
+	delete(t.seen, sender)
+}
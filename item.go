@@ -9,6 +9,7 @@ import (
 	"image/draw"
 	"os"
 	"slices"
+	"sync"
 	"sync/atomic"
 
 	"deedles.dev/tray/internal/set"
@@ -63,6 +64,20 @@ type Item struct {
 	menu    *Menu
 	name    string
 	handler atomic.Pointer[Handler]
+
+	queue        eventQueue
+	dispatchMode atomic.Int32
+	errorHandler atomic.Pointer[func(error)]
+	middleware   atomic.Pointer[[]Middleware]
+	noRecover    atomic.Bool
+
+	eventsM      sync.Mutex
+	events       *eventStream
+	eventsClosed bool
+
+	watcher      atomic.Pointer[string]
+	accessPolicy atomic.Pointer[AccessPolicy]
+	callers      sync.Map
 }
 
 // New creates a new Item configured with the given props. It is
@@ -136,6 +151,11 @@ func (item *Item) export(props []ItemProp) error {
 		return fmt.Errorf("create menu: %w", err)
 	}
 
+	err = item.watchCallers()
+	if err != nil {
+		return fmt.Errorf("watch for disconnected callers: %w", err)
+	}
+
 	err = item.SetProps(props...)
 	if err != nil {
 		return fmt.Errorf("set properties: %w", err)
@@ -147,6 +167,8 @@ func (item *Item) export(props []ItemProp) error {
 		method := fmt.Sprintf("%v.RegisterStatusNotifierItem", watcher)
 		err = dbusCall(item.conn.Object(watcher, "/StatusNotifierWatcher"), method, 0, item.name).Store()
 		if err == nil {
+			item.watcher.Store(&watcher)
+			item.emitEvent(RegisteredEvent{Watcher: watcher})
 			break
 		}
 		errs = append(errs, fmt.Errorf("register StatusNotifierItem with %v: %w", watcher, err))
@@ -202,6 +224,7 @@ func (item *Item) exportIntrospect() error {
 // this Item or on any associated Menu or MenuItem instances after
 // calling this is undefined.
 func (item *Item) Close() error {
+	defer item.closeEvents()
 	return item.conn.Close()
 }
 
@@ -0,0 +1,109 @@
+package tray
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EscapeLabel escapes text so that it is displayed literally when used
+// as a label. Labels use underscores to mark the access key, or
+// mnemonic, of a menu item, so each underscore is doubled.
+func EscapeLabel(text string) string {
+	return strings.ReplaceAll(text, "_", "__")
+}
+
+// UnescapeLabel returns the text that the environment displays for
+// label along with its mnemonic. A single underscore marks the
+// character after it as the mnemonic and is removed, while a double
+// underscore is displayed as one. Only the first mnemonic counts. If
+// there is none, the returned rune is 0.
+func UnescapeLabel(label string) (text string, mnemonic rune) {
+	var sb strings.Builder
+	sb.Grow(len(label))
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if c != '_' || i == len(label)-1 {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		if label[i] == '_' {
+			sb.WriteByte('_')
+			continue
+		}
+		if mnemonic == 0 {
+			mnemonic, _ = utf8.DecodeRuneInString(label[i:])
+		}
+		sb.WriteByte(label[i])
+	}
+	return sb.String(), mnemonic
+}
+
+// validateMnemonic returns an error unless label marks exactly one
+// mnemonic and that mnemonic is a visible character.
+func validateMnemonic(label string) error {
+	var count int
+	for i := 0; i < len(label); i++ {
+		if label[i] != '_' {
+			continue
+		}
+
+		i++
+		if i == len(label) {
+			return errors.New("underscore at end of label")
+		}
+		if label[i] == '_' {
+			continue
+		}
+
+		r, _ := utf8.DecodeRuneInString(label[i:])
+		if !unicode.IsGraphic(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("invalid mnemonic %q", r)
+		}
+		count++
+	}
+
+	switch count {
+	case 0:
+		return errors.New("no mnemonic")
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("%v mnemonics", count)
+	}
+}
+
+// DisplayLabel returns the item's label as it is displayed by the
+// environment along with its mnemonic, if it has one. See
+// [UnescapeLabel].
+func (item *MenuItem) DisplayLabel() (text string, mnemonic rune) {
+	return UnescapeLabel(item.Label())
+}
+
+// MenuItemLabelLiteral sets a MenuItem's "label" property such that
+// text is displayed as is, escaping any underscores in it so that they
+// aren't interpreted as marking a mnemonic. This should be used for
+// text that doesn't come from the program itself, such as file names.
+func MenuItemLabelLiteral(text string) MenuItemProp {
+	return MenuItemLabel(EscapeLabel(text))
+}
+
+// MenuItemLabelMnemonic sets a MenuItem's "label" property to a label
+// that marks its mnemonic with an underscore, such as "_Open". Literal
+// underscores must be doubled. If label does not mark exactly one
+// mnemonic, the property is left unchanged and an error is returned.
+func MenuItemLabelMnemonic(label string) MenuItemProp {
+	return func(item *menuItemProps) {
+		err := validateMnemonic(label)
+		if err != nil {
+			item.catch(fmt.Errorf("invalid label %q: %w", label, err))
+			return
+		}
+
+		MenuItemLabel(label)(item)
+	}
+}
@@ -0,0 +1,68 @@
+package tray
+
+import "testing"
+
+func TestUnescapeLabel(t *testing.T) {
+	tests := []struct {
+		label    string
+		text     string
+		mnemonic rune
+	}{
+		{label: "", text: ""},
+		{label: "Open", text: "Open"},
+		{label: "_Open", text: "Open", mnemonic: 'O'},
+		{label: "Save _As", text: "Save As", mnemonic: 'A'},
+		{label: "a_b_c", text: "abc", mnemonic: 'b'},
+		{label: "file__name", text: "file_name"},
+		{label: "___y", text: "_y", mnemonic: 'y'},
+		{label: "__", text: "_"},
+		{label: "x_", text: "x_"},
+		{label: "_é", text: "é", mnemonic: 'é'},
+	}
+
+	for _, test := range tests {
+		text, mnemonic := UnescapeLabel(test.label)
+		if text != test.text || mnemonic != test.mnemonic {
+			t.Errorf("UnescapeLabel(%q) = %q, %q; want %q, %q", test.label, text, mnemonic, test.text, test.mnemonic)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	for _, text := range []string{"", "plain", "file_name", "__", "_x_"} {
+		got, mnemonic := UnescapeLabel(EscapeLabel(text))
+		if got != text || mnemonic != 0 {
+			t.Errorf("UnescapeLabel(EscapeLabel(%q)) = %q, %q", text, got, mnemonic)
+		}
+	}
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	tests := []struct {
+		label string
+		err   string
+	}{
+		{label: "_Open"},
+		{label: "___y"},
+		{label: "file__name _x"},
+		{label: "Open", err: "no mnemonic"},
+		{label: "__", err: "no mnemonic"},
+		{label: "a_b_c", err: "2 mnemonics"},
+		{label: "x_", err: "underscore at end of label"},
+		{label: "_ x", err: `invalid mnemonic ' '`},
+		{label: "_\t", err: `invalid mnemonic '\t'`},
+	}
+
+	for _, test := range tests {
+		err := validateMnemonic(test.label)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("validateMnemonic(%q): %v", test.label, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.err {
+			t.Errorf("validateMnemonic(%q) = %v; want %q", test.label, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+
+	"deedles.dev/tray/internal/set"
+)
+
+// MenuActions is a registry of named event handlers. Menu definitions
+// loaded with [Menu.Load] refer to handlers by name via their "action"
+// field.
+type MenuActions map[string]MenuEventHandler
+
+// MenuLoadError is returned when a menu definition fails to load.
+type MenuLoadError struct {
+	// File is the name of the file that the definition was loaded
+	// from, if any.
+	File string
+
+	// Line and Column are the 1-based position in the definition at
+	// which the error was detected. They are zero if the position is
+	// unknown.
+	Line, Column int
+
+	// Field is the path to the field that caused the error, such as
+	// "items[2].children[0].type", if applicable.
+	Field string
+
+	Err error
+}
+
+func (err *MenuLoadError) Error() string {
+	var buf bytes.Buffer
+	if err.File != "" {
+		buf.WriteString(err.File)
+		buf.WriteByte(':')
+	}
+	if err.Line > 0 {
+		fmt.Fprintf(&buf, "%v:%v:", err.Line, err.Column)
+	}
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	if err.Field != "" {
+		buf.WriteString(err.Field)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(err.Err.Error())
+	return buf.String()
+}
+
+func (err *MenuLoadError) Unwrap() error {
+	return err.Err
+}
+
+// ParseMenuSpec parses a menu definition from r. The definition is a
+// JSON object with a single "items" field containing an array of
+// items. Each item is an object with any of the following fields, all
+// of which are optional:
+//
+//	{
+//		"key": "open",                   // See MenuSpec.Key.
+//		"type": "standard",              // "standard" or "separator".
+//		"label": "_Open",
+//		"enabled": true,
+//		"visible": true,
+//		"icon-name": "document-open",
+//		"icon-data": "iVBORw0KGgo...",   // Base64-encoded PNG.
+//		"shortcut": "Ctrl+O",            // Or [["Control", "o"]].
+//		"toggle-type": "checkmark",      // "", "checkmark", or "radio".
+//		"toggle-state": 1,               // 0 for off, 1 for on, or -1.
+//		"disposition": "warning",        // "normal", "informative", "warning", or "alert".
+//		"accessible-desc": "Open a file",
+//		"vendor": {"kde": {"prop": 3}},  // Vendor-specific properties.
+//		"action": "open",                // Name of a handler in actions.
+//		"children": []                   // Child items.
+//	}
+//
+// Vendor property values may be any JSON value. Numbers without a
+// fractional part are sent as 32-bit integers and other numbers as
+// doubles. Keys must be unique among siblings.
+//
+// Errors are reported as a [*MenuLoadError] indicating the location
+// of and field responsible for the error.
+func ParseMenuSpec(r io.Reader, actions MenuActions) ([]MenuSpec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &MenuLoadError{Err: err}
+	}
+
+	l := menuLoader{
+		data:    data,
+		dec:     json.NewDecoder(bytes.NewReader(data)),
+		actions: actions,
+	}
+	l.dec.UseNumber()
+	return l.root()
+}
+
+// Load parses a menu definition from r and applies it to the menu with
+// [Menu.Reconcile]. See [ParseMenuSpec] for the format.
+func (menu *Menu) Load(r io.Reader, actions MenuActions) error {
+	spec, err := ParseMenuSpec(r, actions)
+	if err != nil {
+		return err
+	}
+	return menu.Reconcile(spec)
+}
+
+// LoadFile is like [Menu.Load] but reads the definition from the named
+// file.
+func (menu *Menu) LoadFile(path string, actions MenuActions) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = menu.Load(file, actions)
+	var lerr *MenuLoadError
+	if errors.As(err, &lerr) {
+		lerr.File = path
+	}
+	return err
+}
+
+type menuLoader struct {
+	data    []byte
+	dec     *json.Decoder
+	actions MenuActions
+}
+
+func (l *menuLoader) position(offset int64) (line, col int) {
+	offset = min(max(offset, 0), int64(len(l.data)))
+
+	// Offsets usually point to the end of the previous token, so skip
+	// past any separators to get to the start of the next one.
+	for offset < int64(len(l.data)) && bytes.IndexByte([]byte(" \t\r\n,:"), l.data[offset]) >= 0 {
+		offset++
+	}
+
+	before := l.data[:offset]
+	line = bytes.Count(before, []byte{'\n'}) + 1
+	col = int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, col
+}
+
+func (l *menuLoader) errorAt(offset int64, field string, err error) error {
+	var serr *json.SyntaxError
+	if errors.As(err, &serr) {
+		offset = serr.Offset
+	}
+	var terr *json.UnmarshalTypeError
+	if errors.As(err, &terr) {
+		// The offsets of type errors are relative to the start of the
+		// value being decoded, so the offset of the field is used
+		// instead.
+		err = fmt.Errorf("expected %v but found %v", terr.Type, terr.Value)
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+
+	line, col := l.position(offset)
+	return &MenuLoadError{Line: line, Column: col, Field: field, Err: err}
+}
+
+func (l *menuLoader) errorf(offset int64, field string, format string, args ...any) error {
+	return l.errorAt(offset, field, fmt.Errorf(format, args...))
+}
+
+func (l *menuLoader) delim(field string, want json.Delim) error {
+	offset := l.dec.InputOffset()
+	tok, err := l.dec.Token()
+	if err != nil {
+		return l.errorAt(offset, field, err)
+	}
+	if tok != want {
+		return l.errorf(offset, field, "expected %v but found %v", want, tok)
+	}
+	return nil
+}
+
+// object reads a JSON object, calling field for each key. field must
+// consume the corresponding value.
+func (l *menuLoader) object(path string, field func(key string, offset int64) error) error {
+	err := l.delim(path, '{')
+	if err != nil {
+		return err
+	}
+
+	for l.dec.More() {
+		offset := l.dec.InputOffset()
+		tok, err := l.dec.Token()
+		if err != nil {
+			return l.errorAt(offset, path, err)
+		}
+
+		err = field(tok.(string), offset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return l.delim(path, '}')
+}
+
+func (l *menuLoader) decode(offset int64, field string, v any) error {
+	err := l.dec.Decode(v)
+	if err != nil {
+		return l.errorAt(offset, field, err)
+	}
+	return nil
+}
+
+func (l *menuLoader) root() ([]MenuSpec, error) {
+	var items []MenuSpec
+	err := l.object("", func(key string, offset int64) (err error) {
+		switch key {
+		case "items":
+			items, err = l.items("items")
+			return err
+		default:
+			return l.errorf(offset, key, "unknown field")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	offset := l.dec.InputOffset()
+	_, err = l.dec.Token()
+	if err != io.EOF {
+		return nil, l.errorf(offset, "", "unexpected data after menu definition")
+	}
+
+	return items, nil
+}
+
+func (l *menuLoader) items(path string) ([]MenuSpec, error) {
+	err := l.delim(path, '[')
+	if err != nil {
+		return nil, err
+	}
+
+	var items []MenuSpec
+	keys := make(set.Set[string])
+	for i := 0; l.dec.More(); i++ {
+		item, err := l.item(path+"["+strconv.FormatInt(int64(i), 10)+"]", keys)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	err = l.delim(path, ']')
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// item reads a single item. keys holds the keys of the item's siblings
+// that have already been read, which its own key is added to.
+func (l *menuLoader) item(path string, keys set.Set[string]) (spec MenuSpec, err error) {
+	err = l.object(path, func(key string, offset int64) (err error) {
+		field := path + "." + key
+		switch key {
+		case "key":
+			err := l.decode(offset, field, &spec.Key)
+			if err != nil {
+				return err
+			}
+			if spec.Key == "" {
+				return nil
+			}
+			if keys.Contains(spec.Key) {
+				return l.errorf(offset, field, "duplicate key %q", spec.Key)
+			}
+			keys.Add(spec.Key)
+			return nil
+
+		case "label":
+			return l.decode(offset, field, &spec.Label)
+
+		case "children":
+			spec.Children, err = l.items(field)
+			return err
+
+		case "action":
+			var name string
+			err := l.decode(offset, field, &name)
+			if err != nil {
+				return err
+			}
+			h, ok := l.actions[name]
+			if !ok {
+				return l.errorf(offset, field, "unknown action %q", name)
+			}
+			spec.Handler = h
+			return nil
+
+		case "vendor":
+			var vendors map[string]map[string]any
+			err := l.decode(offset, field, &vendors)
+			if err != nil {
+				return err
+			}
+			for vendor, props := range vendors {
+				for prop, v := range props {
+					if v == nil {
+						return l.errorf(offset, field+"."+vendor+"."+prop, "value must not be null")
+					}
+					spec.Props = append(spec.Props, MenuItemVendorProp(vendor, prop, vendorValue(v)))
+				}
+			}
+			return nil
+
+		default:
+			prop, err := l.prop(key, offset, field)
+			if err != nil {
+				return err
+			}
+			spec.Props = append(spec.Props, prop)
+			return nil
+		}
+	})
+	return spec, err
+}
+
+func (l *menuLoader) prop(key string, offset int64, field string) (MenuItemProp, error) {
+	switch key {
+	case "type":
+		var t MenuType
+		err := l.decode(offset, field, &t)
+		if err != nil {
+			return nil, err
+		}
+		if t != Standard && t != Separator {
+			return nil, l.errorf(offset, field, "unknown type %q", t)
+		}
+		return MenuItemType(t), nil
+
+	case "enabled":
+		var enabled bool
+		err := l.decode(offset, field, &enabled)
+		return MenuItemEnabled(enabled), err
+
+	case "visible":
+		var visible bool
+		err := l.decode(offset, field, &visible)
+		return MenuItemVisible(visible), err
+
+	case "icon-name":
+		var name string
+		err := l.decode(offset, field, &name)
+		return MenuItemIconName(name), err
+
+	case "icon-data":
+		var data []byte
+		err := l.decode(offset, field, &data)
+		if err != nil {
+			return nil, err
+		}
+		_, err = png.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, l.errorf(offset, field, "decode PNG: %w", err)
+		}
+		return MenuItemIconPNG(data), nil
+
+	case "shortcut":
+		var raw json.RawMessage
+		err := l.decode(offset, field, &raw)
+		if err != nil {
+			return nil, err
+		}
+
+		var str string
+		if json.Unmarshal(raw, &str) == nil {
+			shortcut, err := ParseShortcut(str)
+			if err != nil {
+				return nil, l.errorAt(offset, field, err)
+			}
+			return MenuItemKeyShortcut(shortcut), nil
+		}
+
+		var shortcut [][]string
+		err = json.Unmarshal(raw, &shortcut)
+		if err != nil {
+			return nil, l.errorf(offset, field, "expected string or array of arrays of strings")
+		}
+		return MenuItemShortcut(shortcut), nil
+
+	case "toggle-type":
+		var t MenuToggleType
+		err := l.decode(offset, field, &t)
+		if err != nil {
+			return nil, err
+		}
+		if t != NonToggleable && t != Checkmark && t != Radio {
+			return nil, l.errorf(offset, field, "unknown toggle type %q", t)
+		}
+		return MenuItemToggleType(t), nil
+
+	case "toggle-state":
+		var state MenuToggleState
+		err := l.decode(offset, field, &state)
+		return MenuItemToggleState(state), err
+
+	case "disposition":
+		var d MenuDisposition
+		err := l.decode(offset, field, &d)
+		if err != nil {
+			return nil, err
+		}
+		switch d {
+		case DispositionNormal, DispositionInformative, DispositionWarning, DispositionAlert:
+		default:
+			return nil, l.errorf(offset, field, "unknown disposition %q", d)
+		}
+		return MenuItemDisposition(d), nil
+
+	case "accessible-desc":
+		var desc string
+		err := l.decode(offset, field, &desc)
+		return MenuItemAccessibleDesc(desc), err
+
+	default:
+		return nil, l.errorf(offset, field, "unknown field")
+	}
+}
+
+func vendorValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		i, err := strconv.ParseInt(string(v), 10, 32)
+		if err == nil {
+			return int32(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = vendorValue(v[i])
+		}
+		return v
+	case map[string]any:
+		for k := range v {
+			v[k] = vendorValue(v[k])
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,160 @@
+package tray
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMenuSpecErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		line, col int
+		field     string
+		err       string
+	}{
+		{
+			name: "UnknownField",
+			in:   `{"items": [{"bogus": 1}]}`,
+			line: 1, col: 13,
+			field: "items[0].bogus",
+			err:   "unknown field",
+		},
+		{
+			name: "UnknownRootField",
+			in:   `{"bogus": []}`,
+			line: 1, col: 2,
+			field: "bogus",
+			err:   "unknown field",
+		},
+		{
+			name: "NestedType",
+			in:   `{"items": [{}, {"children": [{"type": "x"}]}]}`,
+			line: 1, col: 31,
+			field: "items[1].children[0].type",
+			err:   `unknown type "x"`,
+		},
+		{
+			name: "WrongType",
+			in:   `{"items": [{"label": 3}]}`,
+			line: 1, col: 13,
+			field: "items[0].label",
+			err:   "expected string but found number",
+		},
+		{
+			name: "UnknownAction",
+			in:   `{"items": [{"action": "nope"}]}`,
+			line: 1, col: 13,
+			field: "items[0].action",
+			err:   `unknown action "nope"`,
+		},
+		{
+			name: "Shortcut",
+			in:   `{"items": [{"shortcut": "Ctrl+"}]}`,
+			line: 1, col: 13,
+			field: "items[0].shortcut",
+			err:   "missing key",
+		},
+		{
+			name: "DuplicateKey",
+			in: `{
+  "items": [
+    {"key": "a"},
+    {"key": "a"}
+  ]
+}`,
+			line: 4, col: 6,
+			field: "items[1].key",
+			err:   `duplicate key "a"`,
+		},
+		{
+			name: "DuplicateNestedKey",
+			in:   `{"items": [{"key": "a"}, {"children": [{"key": "a"}, {"key": "a"}]}]}`,
+			line: 1, col: 55,
+			field: "items[1].children[1].key",
+			err:   `duplicate key "a"`,
+		},
+		{
+			name: "TrailingData",
+			in:   `{"items": []} x`,
+			line: 1, col: 15,
+			err: "unexpected data after menu definition",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseMenuSpec(strings.NewReader(test.in), nil)
+			var lerr *MenuLoadError
+			if !errors.As(err, &lerr) {
+				t.Fatalf("expected *MenuLoadError, got %#v", err)
+			}
+			if lerr.Line != test.line || lerr.Column != test.col || lerr.Field != test.field {
+				t.Errorf("error at %v:%v %q; want %v:%v %q", lerr.Line, lerr.Column, lerr.Field, test.line, test.col, test.field)
+			}
+			if !strings.Contains(lerr.Err.Error(), test.err) {
+				t.Errorf("error %q; want %q", lerr.Err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	item, _ := newTestItem(t)
+
+	var clicked bool
+	actions := MenuActions{
+		"open": func(MenuEventID, any, uint32) error {
+			clicked = true
+			return nil
+		},
+	}
+
+	err := item.menu.Load(strings.NewReader(`{"items": [
+		{"key": "file", "label": "_File", "children": [
+			{"key": "open", "label": "_Open", "action": "open", "enabled": false},
+			{"key": "sep", "type": "separator"}
+		]},
+		{"label": "Vendor", "vendor": {"kde": {"n": 3, "f": 1.5}}}
+	]}`), actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if layout := renderMenu(item.menu, specKey); layout != "file(open sep) Vendor" {
+		t.Fatalf("layout = %q", layout)
+	}
+
+	open := item.menu.nodes[item.menu.nodes[item.menu.children[0]].children[0]]
+	if open.props["label"] != "_Open" || open.props["enabled"] != false {
+		t.Errorf("unexpected properties %v", open.props)
+	}
+	open.handler(Clicked, nil, 0)
+	if !clicked {
+		t.Errorf("action was not called")
+	}
+
+	vendor := item.menu.nodes[item.menu.children[1]]
+	if vendor.props["x-kde-n"] != int32(3) || vendor.props["x-kde-f"] != 1.5 {
+		t.Errorf("unexpected vendor properties %v", vendor.props)
+	}
+}
+
+func TestParseMenuSpecKeys(t *testing.T) {
+	in := `{"items": [
+		{"key": "a", "children": [{"key": "a"}]},
+		{"key": "b"},
+		{"key": ""},
+		{"key": ""},
+		{}
+	]}`
+
+	spec, err := ParseMenuSpec(strings.NewReader(in), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec) != 5 || len(spec[0].Children) != 1 {
+		t.Fatalf("unexpected spec: %#v", spec)
+	}
+}
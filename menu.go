@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"sync"
 
-	"deedles.dev/tray/internal/set"
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/introspect"
 	"github.com/godbus/dbus/v5/prop"
@@ -38,15 +37,24 @@ type Menu struct {
 	nodes    map[int]*MenuItem
 	children []int
 	revision uint32
-	dirty    set.Set[int]
+	dirty    dirtyTracker
 	handler  MenuEventHandler
+
+	// batch collects changes instead of them being emitted while the
+	// changes recorded by Batch are being made.
+	batch *menuChanges
+
+	compat menuCompat
+	icons  iconCache
 }
 
 func (item *Item) createMenu() error {
 	item.menu = &Menu{
 		item:  item,
 		nodes: make(map[int]*MenuItem),
-		dirty: make(set.Set[int]),
+		compat: menuCompat{
+			fullPropsHosts: defaultFullPropsHosts,
+		},
 	}
 	err := item.menu.export()
 	if err != nil {
@@ -120,19 +128,53 @@ func (menu *Menu) updateLayout(nodes ...menuNode) error {
 		panic("no nodes given")
 	}
 
+	ids := make([]int, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.getID())
+	}
+	return menu.emitLayoutUpdated(ids...)
+}
+
+func (menu *Menu) emitLayoutUpdated(ids ...int) error {
+	if menu.batch != nil {
+		for _, id := range ids {
+			menu.batch.markLayout(id)
+			menu.dirty.mark(id)
+		}
+		return nil
+	}
+
 	menu.revision++
 
-	errs := make([]error, 0, len(nodes))
-	for _, node := range nodes {
-		id := node.getID()
+	errs := make([]error, 0, len(ids))
+	for _, id := range ids {
 		err := menu.item.conn.Emit(menuPath, "com.canonical.dbusmenu.LayoutUpdated", menu.revision, id)
 		errs = append(errs, err)
-		menu.dirty.Add(id)
+		menu.dirty.mark(id)
 	}
 
 	return errors.Join(errs...)
 }
 
+func (menu *Menu) emitItemsPropertiesUpdated(updated []menuProps, removed []menuRemovedProps) error {
+	if menu.batch != nil {
+		menu.batch.addProps(updated, removed)
+		return nil
+	}
+
+	return menu.item.conn.Emit(
+		menuPath,
+		"com.canonical.dbusmenu.ItemsPropertiesUpdated",
+		updated,
+		removed,
+	)
+}
+
+// Item returns the Item that the menu belongs to.
+func (menu *Menu) Item() *Item {
+	return menu.item
+}
+
 // TextDirection returns the current value of the menu's TextDirection
 // property.
 func (menu *Menu) TextDirection() TextDirection {
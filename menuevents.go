@@ -0,0 +1,168 @@
+package tray
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// MenuEventHandlers is a set of optional handlers for the different
+// kinds of menu events. Its Handle method is a [MenuEventHandler], so
+// it can be used as such via a method value:
+//
+//	tray.MenuItemHandler(tray.MenuEventHandlers{
+//		OnClicked: onClicked,
+//		OnHovered: onHovered,
+//	}.Handle)
+//
+// Vendor-specific events are passed to Vendor, if it is not nil. Any
+// event that isn't handled by one of the other fields is passed
+// through to Fallback. If Fallback is also nil, the event is ignored.
+type MenuEventHandlers struct {
+	OnClicked func(data any, timestamp uint32) error
+	OnHovered func(data any, timestamp uint32) error
+	OnOpened  func(data any, timestamp uint32) error
+	OnClosed  func(data any, timestamp uint32) error
+
+	Vendor *VendorEvents
+
+	Fallback MenuEventHandler
+}
+
+// Handle calls the appropriate handler for the event.
+func (h MenuEventHandlers) Handle(eventID MenuEventID, data any, timestamp uint32) error {
+	var handler func(any, uint32) error
+	switch eventID {
+	case Clicked:
+		handler = h.OnClicked
+	case Hovered:
+		handler = h.OnHovered
+	case Opened:
+		handler = h.OnOpened
+	case Closed:
+		handler = h.OnClosed
+	}
+	if handler != nil {
+		return handler(data, timestamp)
+	}
+
+	if h.Vendor != nil {
+		vendor, event, ok := eventID.ParseVendor()
+		if ok {
+			handler := h.Vendor.Lookup(vendor)
+			if handler != nil {
+				return handler(event, data, timestamp)
+			}
+		}
+	}
+
+	if h.Fallback != nil {
+		return h.Fallback(eventID, data, timestamp)
+	}
+	return nil
+}
+
+// VendorEventHandler handles vendor-specific events for a single
+// vendor. It is given the name of the event with the "x-<vendor>-"
+// prefix removed.
+type VendorEventHandler func(event string, data any, timestamp uint32) error
+
+// VendorEvents is a registry of handlers for vendor-specific menu
+// events of the form "x-<vendor>-<event>", keyed by vendor. Its Handle
+// method is a [MenuEventHandler]. The zero value is an empty registry
+// ready to use. It is safe to register handlers concurrently with
+// events being handled.
+type VendorEvents struct {
+	m        sync.RWMutex
+	handlers map[string]VendorEventHandler
+}
+
+// Register sets the handler for events from the given vendor,
+// replacing any existing one. A nil handler removes the existing one.
+func (r *VendorEvents) Register(vendor string, handler VendorEventHandler) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if handler == nil {
+		delete(r.handlers, vendor)
+		return
+	}
+
+	if r.handlers == nil {
+		r.handlers = make(map[string]VendorEventHandler)
+	}
+	r.handlers[vendor] = handler
+}
+
+// Lookup returns the handler registered for the given vendor, or nil
+// if there isn't one.
+func (r *VendorEvents) Lookup(vendor string) VendorEventHandler {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.handlers[vendor]
+}
+
+// Handle calls the handler registered for the vendor of eventID. It
+// does nothing if eventID is not vendor-specific or if there is no
+// handler registered for its vendor.
+func (r *VendorEvents) Handle(eventID MenuEventID, data any, timestamp uint32) error {
+	vendor, event, ok := eventID.ParseVendor()
+	if !ok {
+		return nil
+	}
+
+	handler := r.Lookup(vendor)
+	if handler == nil {
+		return nil
+	}
+	return handler(event, data, timestamp)
+}
+
+// MenuEventData extracts a value of type T from the data passed to a
+// [MenuEventHandler]. Any D-Bus variants wrapping the value are
+// unwrapped first. Numeric values are converted to T if T is a
+// numeric type, as are strings to other string types. The returned
+// bool is false if the value could not be extracted.
+func MenuEventData[T any](data any) (T, bool) {
+	for {
+		v, ok := data.(dbus.Variant)
+		if !ok {
+			break
+		}
+		data = v.Value()
+	}
+
+	if v, ok := data.(T); ok {
+		return v, true
+	}
+
+	var zero T
+	rv := reflect.ValueOf(data)
+	if !rv.IsValid() {
+		return zero, false
+	}
+
+	t := reflect.TypeFor[T]()
+	if kindClass(rv.Kind()) == 0 || kindClass(rv.Kind()) != kindClass(t.Kind()) {
+		return zero, false
+	}
+	if !rv.Type().ConvertibleTo(t) {
+		return zero, false
+	}
+	return rv.Convert(t).Interface().(T), true
+}
+
+func kindClass(k reflect.Kind) int {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return 1
+	case reflect.String:
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,244 @@
+package tray
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"slices"
+	"sync"
+)
+
+// iconCacheSize is the maximum number of encoded icons kept by a
+// menu's icon cache.
+const iconCacheSize = 256
+
+type iconKey [sha256.Size]byte
+
+// iconCache caches PNG encodings of menu icons keyed by a hash of
+// their content so that setting the same icon repeatedly, or on many
+// items, only encodes it once.
+type iconCache struct {
+	m       sync.Mutex
+	size    int
+	encoded map[iconKey][]byte
+	order   []iconKey
+}
+
+func (c *iconCache) targetSize() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.size
+}
+
+func (c *iconCache) setTargetSize(size int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.size = max(size, 0)
+}
+
+// get returns the encoding with the given key, calling encode to
+// create it if it is not already cached. encode is called without the
+// cache locked.
+func (c *iconCache) get(key iconKey, encode func() ([]byte, error)) ([]byte, error) {
+	c.m.Lock()
+	data, ok := c.encoded[key]
+	c.m.Unlock()
+	if ok {
+		return data, nil
+	}
+
+	data, err := encode()
+	if err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.encoded == nil {
+		c.encoded = make(map[iconKey][]byte)
+	}
+	if _, ok := c.encoded[key]; !ok {
+		if len(c.order) >= iconCacheSize {
+			delete(c.encoded, c.order[0])
+			c.order = slices.Delete(c.order, 0, 1)
+		}
+		c.order = append(c.order, key)
+	}
+	c.encoded[key] = data
+	return data, nil
+}
+
+// pixelKey hashes the pixels of img along with the size that it is to
+// be scaled to.
+func pixelKey(img *image.NRGBA, size int) iconKey {
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, [...]int64{
+		int64(img.Rect.Dx()),
+		int64(img.Rect.Dy()),
+		int64(size),
+	})
+	h.Write([]byte{'p'})
+	for y := range img.Rect.Dy() {
+		i := y * img.Stride
+		h.Write(img.Pix[i : i+4*img.Rect.Dx()])
+	}
+	return iconKey(h.Sum(nil))
+}
+
+// pngKey hashes PNG-encoded data along with the size that it is to be
+// scaled to.
+func pngKey(data []byte, size int) iconKey {
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, int64(size))
+	h.Write([]byte{'e'})
+	h.Write(data)
+	return iconKey(h.Sum(nil))
+}
+
+// encodeIcon returns img encoded as a PNG, scaled to fit the menu's
+// icon size if one is set.
+func (menu *Menu) encodeIcon(img image.Image) ([]byte, error) {
+	size := menu.icons.targetSize()
+	src := toNRGBA(img)
+	return menu.icons.get(pixelKey(src, size), func() ([]byte, error) {
+		return encodePNG(scaleToFit(src, size))
+	})
+}
+
+// encodedIcon returns data, which must already be PNG-encoded, scaled
+// to fit the menu's icon size if one is set.
+func (menu *Menu) encodedIcon(data []byte) ([]byte, error) {
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode PNG: %w", err)
+	}
+
+	size := menu.icons.targetSize()
+	if !needsScale(config.Width, config.Height, size) {
+		return slices.Clone(data), nil
+	}
+
+	return menu.icons.get(pngKey(data, size), func() ([]byte, error) {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decode PNG: %w", err)
+		}
+		return encodePNG(scaleToFit(toNRGBA(img), size))
+	})
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// toNRGBA returns img as an *image.NRGBA with its bounds starting at
+// the origin. Pixmaps are converted directly instead of going through
+// the generic, and much slower, color conversion.
+func toNRGBA(img image.Image) *image.NRGBA {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		if img.Rect.Min == (image.Point{}) {
+			return img
+		}
+	case *Pixmap:
+		return pixmapToNRGBA(*img)
+	case Pixmap:
+		return pixmapToNRGBA(img)
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Rect, img, bounds.Min, draw.Src)
+	return dst
+}
+
+func pixmapToNRGBA(p Pixmap) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, p.Width, p.Height))
+	for i := 0; i+4 <= len(p.Data) && i+4 <= len(dst.Pix); i += 4 {
+		a, r, g, b := p.Data[i], p.Data[i+1], p.Data[i+2], p.Data[i+3]
+		dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = r, g, b, a
+	}
+	return dst
+}
+
+func needsScale(width, height, size int) bool {
+	return size > 0 && max(width, height) != size
+}
+
+// scaleToFit scales img, preserving its aspect ratio, such that its
+// larger dimension is size. If size is 0, img is returned as is.
+func scaleToFit(img *image.NRGBA, size int) *image.NRGBA {
+	sw, sh := img.Rect.Dx(), img.Rect.Dy()
+	if !needsScale(sw, sh, size) || sw == 0 || sh == 0 {
+		return img
+	}
+
+	dw, dh := size, size
+	if sw > sh {
+		dh = max(sh*size/sw, 1)
+	} else {
+		dw = max(sw*size/sh, 1)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0 := y * sh / dh
+		y1 := max((y+1)*sh/dh, y0+1)
+		for x := range dw {
+			x0 := x * sw / dw
+			x1 := max((x+1)*sw/dw, x0+1)
+			boxFilter(dst.Pix[dst.PixOffset(x, y):], img, x0, y0, x1, y1)
+		}
+	}
+	return dst
+}
+
+// boxFilter averages the pixels of src in the given rectangle into
+// the first four bytes of dst. The color channels are weighted by
+// alpha so that fully transparent pixels don't affect the result.
+func boxFilter(dst []byte, src *image.NRGBA, x0, y0, x1, y1 int) {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			p := src.Pix[src.PixOffset(x, y):]
+			pa := uint64(p[3])
+			r += uint64(p[0]) * pa
+			g += uint64(p[1]) * pa
+			b += uint64(p[2]) * pa
+			a += pa
+			n++
+		}
+	}
+
+	if a == 0 {
+		dst[0], dst[1], dst[2], dst[3] = 0, 0, 0, 0
+		return
+	}
+	dst[0] = byte(r / a)
+	dst[1] = byte(g / a)
+	dst[2] = byte(b / a)
+	dst[3] = byte(a / n)
+}
+
+// ItemMenuIconSize sets the size, in pixels, that icons set on menu
+// items with [MenuItemIconData] and [MenuItemIconPNG] are scaled to
+// before being sent, preserving their aspect ratio. Icons that are
+// already the right size are sent as is. It only applies to icons set
+// after it. The default, 0, disables scaling.
+func ItemMenuIconSize(size int) ItemProp {
+	return func(item *itemProps) {
+		item.menu.icons.setTargetSize(size)
+	}
+}
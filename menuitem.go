@@ -14,6 +14,15 @@ import (
 	"deedles.dev/tray/internal/set"
 )
 
+// ErrDetached is returned by methods of a MenuItem that has been
+// removed from its menu. A removed item can be put back into the menu
+// with AppendChild, MoveBefore, and similar methods.
+var ErrDetached = errors.New("menu item is not attached to a menu")
+
+// ErrMoveIntoSelf is returned when attempting to move a MenuItem so
+// that it would become its own descendant.
+var ErrMoveIntoSelf = errors.New("menu item cannot be moved into itself")
+
 // MenuItem is a single item in the menu of the tray item. The item
 // can be a regular single item or can be a sub-menu containing more
 // items, recursively.
@@ -24,42 +33,164 @@ type MenuItem struct {
 	menu   *Menu
 	id     int
 	parent int
+	key    string
+
+	m           sync.RWMutex
+	props       map[string]any
+	children    []int
+	handler     MenuEventHandler
+	aboutToShow func(*MenuItem) error
+	submenu     bool
+	radio       *RadioGroup
+
+	// detached holds the item and all of its descendants while it is
+	// removed from the menu so that they can be restored if it is
+	// reattached. It is protected by the menu's lock, not the item's.
+	detached map[int]*MenuItem
+}
 
-	m        sync.RWMutex
-	props    map[string]any
-	children []int
-	handler  MenuEventHandler
+// allocItem creates a MenuItem with a new ID without adding it to the
+// menu. The menu must be locked.
+func (menu *Menu) allocItem() *MenuItem {
+	menu.id++
+	return &MenuItem{
+		menu:  menu,
+		id:    menu.id,
+		props: make(map[string]any),
+	}
 }
 
 func (menu *Menu) newItem(parent int) *MenuItem {
-	menu.id++
-	item := MenuItem{
-		menu:   menu,
-		id:     menu.id,
-		parent: parent,
-		props:  make(map[string]any),
+	item := menu.allocItem()
+	item.parent = parent
+
+	menu.nodes[item.id] = item
+
+	return item
+}
+
+// detachNode removes item and all of its descendants from the menu's
+// nodes, keeping track of them so that they can be restored by
+// attachNode. The menu must be locked.
+func (menu *Menu) detachNode(item *MenuItem) {
+	item.detached = make(map[int]*MenuItem)
+	menu.collectNodes(item, item.detached)
+}
+
+func (menu *Menu) collectNodes(item *MenuItem, nodes map[int]*MenuItem) {
+	delete(menu.nodes, item.id)
+	nodes[item.id] = item
+
+	item.m.RLock()
+	children := item.children
+	item.m.RUnlock()
+
+	for _, id := range children {
+		child := menu.nodes[id]
+		if child != nil {
+			menu.collectNodes(child, nodes)
+		}
 	}
+}
 
-	menu.nodes[item.id] = &item
+// attachNode restores the nodes removed by detachNode. The menu must
+// be locked.
+func (menu *Menu) attachNode(item *MenuItem) {
+	for id, node := range item.detached {
+		menu.nodes[id] = node
+	}
+	item.detached = nil
+}
 
-	return &item
+// checkAttached returns ErrDetached if item is not currently part of
+// its menu. The menu must be at least read locked.
+func (item *MenuItem) checkAttached() error {
+	if item.menu.nodes[item.id] != item {
+		return ErrDetached
+	}
+	return nil
 }
 
-// AddChild creates a new MenuItem with the given properties and
-// appends it as the last child of the root of the menu hierarchy.
-func (menu *Menu) AddChild(props ...MenuItemProp) (*MenuItem, error) {
-	defer menu.lock()()
+// checkNode is like checkAttached but also accepts the menu itself,
+// which is always attached.
+func checkNode(node menuNode) error {
+	if item, ok := node.(*MenuItem); ok {
+		return item.checkAttached()
+	}
+	return nil
+}
+
+// appendPos is a position function for appending to the end of the
+// children.
+func appendPos(children []int) int {
+	return len(children)
+}
+
+// indexPos returns a position function for inserting at the given
+// index, clamped to the valid range.
+func indexPos(i int) func([]int) int {
+	return func(children []int) int {
+		return min(max(i, 0), len(children))
+	}
+}
+
+// siblingPos returns a position function for inserting relative to
+// sibling, offset by offset. If sibling is not one of the children,
+// the position is the end of the children.
+func siblingPos(sibling *MenuItem, offset int) func([]int) int {
+	return func(children []int) int {
+		i := slices.Index(children, sibling.id)
+		if i < 0 {
+			return len(children)
+		}
+		return i + offset
+	}
+}
 
-	child := menu.newItem(0)
+// insertID inserts id into children at i without modifying the
+// backing array of children, as it may be in use elsewhere.
+func insertID(children []int, i int, id int) []int {
+	return slices.Insert(slices.Clip(children), i, id)
+}
+
+// addChild creates a new MenuItem as a child of parent at the position
+// given by pos. The menu must be locked.
+func addChild(menu *Menu, parent menuNode, pos func([]int) int, props []MenuItemProp) (*MenuItem, error) {
+	if err := checkNode(parent); err != nil {
+		return nil, err
+	}
+
+	child := menu.allocItem()
+	return child, insertChild(menu, parent, child, pos, props)
+}
+
+// insertChild adds child, which must have been created by allocItem
+// and not yet been added to the menu, to parent at the position given
+// by pos and sets the given properties on it. The menu must be locked.
+func insertChild(menu *Menu, parent menuNode, child *MenuItem, pos func([]int) int, props []MenuItemProp) error {
+	if parent != menu {
+		defer parent.lock()()
+	}
+
+	child.parent = parent.getID()
+	menu.nodes[child.id] = child
 	defer child.lock()()
 
-	dirty, errs := child.applyProps(props)
-	errs = append(errs, menu.updateLayout(menu))
-	errs = append(errs, child.emitPropertiesUpdated(dirty))
+	dirty, removed, errs := child.applyProps(props)
+	errs = append(errs, menu.updateLayout(parent))
+	errs = append(errs, child.emitPropertiesUpdated(dirty, removed))
 
-	menu.setChildren(append(menu.children, child.id))
+	c := parent.getChildren()
+	parent.setChildren(insertID(c, pos(c), child.id))
 
-	return child, errors.Join(errs...)
+	return errors.Join(errs...)
+}
+
+// AddChild creates a new MenuItem with the given properties and
+// appends it as the last child of the root of the menu hierarchy.
+func (menu *Menu) AddChild(props ...MenuItemProp) (*MenuItem, error) {
+	defer menu.lock()()
+	return addChild(menu, menu, appendPos, props)
 }
 
 // AddChild creates a new MenuItem with the given properties and
@@ -68,63 +199,153 @@ func (menu *Menu) AddChild(props ...MenuItemProp) (*MenuItem, error) {
 // automatically be converted into a sub-menu MenuItem.
 func (item *MenuItem) AddChild(props ...MenuItemProp) (*MenuItem, error) {
 	defer item.menu.lock()()
-	defer item.lock()()
+	return addChild(item.menu, item, appendPos, props)
+}
 
-	child := item.menu.newItem(item.id)
-	defer child.lock()()
+// InsertChild is like [Menu.AddChild] but inserts the new MenuItem at
+// the given index among the top-level items instead of appending it.
+// An index past the end appends it.
+func (menu *Menu) InsertChild(index int, props ...MenuItemProp) (*MenuItem, error) {
+	defer menu.lock()()
+	return addChild(menu, menu, indexPos(index), props)
+}
 
-	dirty, errs := child.applyProps(props)
-	errs = append(errs, item.menu.updateLayout(item))
-	errs = append(errs, item.emitPropertiesUpdated(dirty))
+// InsertChild is like [MenuItem.AddChild] but inserts the new
+// MenuItem at the given index among item's children instead of
+// appending it. An index past the end appends it.
+func (item *MenuItem) InsertChild(index int, props ...MenuItemProp) (*MenuItem, error) {
+	defer item.menu.lock()()
+	return addChild(item.menu, item, indexPos(index), props)
+}
 
-	item.setChildren(append(item.children, child.id))
+// AddChildBefore is like [Menu.AddChild] but inserts the new MenuItem
+// immediately before sibling. If sibling is not a top-level item, the
+// new MenuItem is appended.
+func (menu *Menu) AddChildBefore(sibling *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	defer menu.lock()()
+	return addChild(menu, menu, siblingPos(sibling, 0), props)
+}
 
-	return child, errors.Join(errs...)
+// AddChildBefore is like [MenuItem.AddChild] but inserts the new
+// MenuItem immediately before sibling. If sibling is not a child of
+// item, the new MenuItem is appended.
+func (item *MenuItem) AddChildBefore(sibling *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	defer item.menu.lock()()
+	return addChild(item.menu, item, siblingPos(sibling, 0), props)
 }
 
-// Remove removes item from the menu hierarchy completely. If its
-// parent is another MenuItem and item is its only child, the parent
-// is converted from a sub-menu item back into a regular one.
+// AddChildAfter is like [Menu.AddChild] but inserts the new MenuItem
+// immediately after sibling. If sibling is not a top-level item, the
+// new MenuItem is appended.
+func (menu *Menu) AddChildAfter(sibling *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	defer menu.lock()()
+	return addChild(menu, menu, siblingPos(sibling, 1), props)
+}
+
+// AddChildAfter is like [MenuItem.AddChild] but inserts the new
+// MenuItem immediately after sibling. If sibling is not a child of
+// item, the new MenuItem is appended.
+func (item *MenuItem) AddChildAfter(sibling *MenuItem, props ...MenuItemProp) (*MenuItem, error) {
+	defer item.menu.lock()()
+	return addChild(item.menu, item, siblingPos(sibling, 1), props)
+}
+
+// Remove removes item and all of its descendants from the menu
+// hierarchy. If its parent is another MenuItem and item is its only
+// child, the parent is converted from a sub-menu item back into a
+// regular one.
+//
+// The removed item keeps its properties, handler, and children and
+// can be put back into the menu later with AppendChild, MoveBefore,
+// and similar methods. Until then, most of its methods, as well as
+// those of its descendants, return [ErrDetached].
 func (item *MenuItem) Remove() error {
-	parent := item.getParent()
-	if parent == nil {
-		return nil
-	}
-	defer parent.lock()()
+	defer item.menu.lock()()
+	return item.remove()
+}
 
-	parent.setChildren(sliceRemove(parent.getChildren(), item.id))
+// remove removes item from the menu. The menu must be locked.
+func (item *MenuItem) remove() error {
+	if err := item.checkAttached(); err != nil {
+		return err
+	}
 
+	parent := item.getParent()
 	if parent != item.menu {
-		defer item.menu.lock()()
+		defer parent.lock()()
 	}
 
-	delete(item.menu.nodes, item.id)
+	parent.setChildren(sliceRemove(slices.Clone(parent.getChildren()), item.id))
+	item.menu.detachNode(item)
 
 	return item.menu.updateLayout(parent)
 }
 
-func appendChild(menu *Menu, dst menuNode, child *MenuItem) error {
-	parent := child.getParent()
-	if parent == nil {
-		// TODO: Allow appending children who have previously been removed?
+// getParent returns the node that item is a child of. The menu must
+// be at least read locked.
+func (item *MenuItem) getParent() menuNode {
+	if item.parent == 0 {
+		return item.menu
+	}
+
+	p, ok := item.menu.nodes[item.parent]
+	if !ok {
 		return nil
 	}
+	return p
+}
 
-	defer dst.lock()()
-	if parent != dst {
-		defer parent.lock()()
+// moveChild moves child from wherever it currently is to the position
+// in dst's children given by pos. If child was removed, it is
+// reattached along with its descendants. Moving child into itself or
+// one of its descendants fails with ErrMoveIntoSelf. The menu must be
+// locked.
+func moveChild(menu *Menu, dst menuNode, child *MenuItem, pos func([]int) int) error {
+	if err := checkNode(dst); err != nil {
+		return err
+	}
+	if child.checkAttached() != nil {
+		return attachChild(menu, dst, child, pos)
+	}
+	if slices.Contains(menu.pathToRoot(dst.getID()), child.id) {
+		return ErrMoveIntoSelf
 	}
 
-	parent.setChildren(sliceRemove(parent.getChildren(), child.id))
-	dst.setChildren(append(dst.getChildren(), child.id))
+	src := child.getParent()
+	if dst != menu {
+		defer dst.lock()()
+	}
+	if src != menu && src != dst {
+		defer src.lock()()
+	}
+
+	src.setChildren(sliceRemove(slices.Clone(src.getChildren()), child.id))
+	c := dst.getChildren()
+	dst.setChildren(insertID(c, pos(c), child.id))
 	child.parent = dst.getID()
 
-	if dst != menu && parent != menu {
-		defer menu.lock()()
+	updates := slices.Compact([]menuNode{dst, src})
+	return menu.updateLayout(updates...)
+}
+
+// attachChild inserts a removed child into dst at the position given
+// by pos. Only the item that was actually removed can be reattached,
+// not one of its descendants. The menu must be locked.
+func attachChild(menu *Menu, dst menuNode, child *MenuItem, pos func([]int) int) error {
+	if child.menu != menu || child.detached == nil {
+		return ErrDetached
 	}
 
-	updates := slices.Compact([]menuNode{dst, parent})
-	return menu.updateLayout(updates...)
+	if dst != menu {
+		defer dst.lock()()
+	}
+
+	c := dst.getChildren()
+	dst.setChildren(insertID(c, pos(c), child.id))
+	child.parent = dst.getID()
+	menu.attachNode(child)
+
+	return menu.updateLayout(dst)
 }
 
 // AppendChild makes child the last child of the root of the menu
@@ -132,92 +353,151 @@ func appendChild(menu *Menu, dst menuNode, child *MenuItem) error {
 // about sub-menu conversion apply as they do in both [AddChild] and
 // [Remove].
 func (menu *Menu) AppendChild(child *MenuItem) error {
-	return appendChild(menu, menu, child)
+	defer menu.lock()()
+	return moveChild(menu, menu, child, appendPos)
 }
 
 // AppendChild makes child the last child of item, moving it from
 // wherever it currently is. The same rules about sub-menu conversion
 // apply as they do in both [AddChild] and [Remove].
 func (item *MenuItem) AppendChild(child *MenuItem) error {
-	return appendChild(item.menu, item, child)
+	defer item.menu.lock()()
+	return moveChild(item.menu, item, child, appendPos)
+}
+
+// MoveToIndex moves child to the given index among the top-level
+// items, moving it from wherever it currently is. An index past the
+// end makes it the last top-level item.
+func (menu *Menu) MoveToIndex(child *MenuItem, index int) error {
+	defer menu.lock()()
+	return moveChild(menu, menu, child, indexPos(index))
+}
+
+// MoveToIndex moves child to the given index among item's children,
+// moving it from wherever it currently is. An index past the end
+// makes it the last child.
+func (item *MenuItem) MoveToIndex(child *MenuItem, index int) error {
+	defer item.menu.lock()()
+	return moveChild(item.menu, item, child, indexPos(index))
+}
+
+// siblingMove moves item relative to sibling. The menu must be
+// locked.
+func (item *MenuItem) siblingMove(sibling *MenuItem, offset int) error {
+	if err := sibling.checkAttached(); err != nil {
+		return err
+	}
+	if item == sibling {
+		return nil
+	}
+
+	return moveChild(item.menu, sibling.getParent(), item, siblingPos(sibling, offset))
 }
 
 // MoveBefore makes item the previous sibling of sibling. If
 // necessary, this method will transfer item from its current parent
 // to sibling's parent.
 func (item *MenuItem) MoveBefore(sibling *MenuItem) error {
-	dst := sibling.getParent()
-	src := item.getParent()
-	if dst == nil || src == nil {
-		// TODO: Allow moving children who have previously been removed?
-		return nil
-	}
+	defer item.menu.lock()()
+	return item.siblingMove(sibling, 0)
+}
 
-	defer dst.lock()()
-	if dst != src {
-		defer src.lock()()
-	}
+// MoveAfter makes item the next sibling of sibling. If necessary,
+// this method will transfer item from its current parent to sibling's
+// parent.
+func (item *MenuItem) MoveAfter(sibling *MenuItem) error {
+	defer item.menu.lock()()
+	return item.siblingMove(sibling, 1)
+}
 
-	dc := dst.getChildren()
-	i := slices.Index(dc, sibling.id)
-	if i < 0 {
-		i = len(dc)
+// sortChildren sorts the children of parent. The menu must be locked.
+func sortChildren(menu *Menu, parent menuNode, less func(a, b *MenuItem) bool) error {
+	if err := checkNode(parent); err != nil {
+		return err
+	}
+	if parent != menu {
+		defer parent.lock()()
 	}
 
-	src.setChildren(sliceRemove(src.getChildren(), item.id))
-	dst.setChildren(slices.Insert(dc, i, item.id))
-	item.parent = dst.getID()
+	children := parent.getChildren()
+	items := menu.snapshot(children, false)
+	slices.SortStableFunc(items, func(a, b *MenuItem) int {
+		switch {
+		case less(a, b):
+			return -1
+		case less(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
 
-	if dst != item.menu && src != item.menu {
-		defer item.menu.lock()()
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.id)
+	}
+	if slices.Equal(ids, children) {
+		return nil
 	}
 
-	updates := slices.Compact([]menuNode{dst, src})
-	return item.menu.updateLayout(updates...)
+	parent.setChildren(ids)
+	return menu.updateLayout(parent)
 }
 
-func (item *MenuItem) applyProps(props []MenuItemProp) (iter.Seq[string], []error) {
-	w := menuItemProps{MenuItem: item, dirty: make(set.Set[string])}
-	for _, p := range props {
-		p(&w)
-	}
-	return maps.Keys(w.dirty), w.errs
+// SortChildren sorts the top-level items using less, which reports
+// whether a should come before b. The sort is stable. A layout update
+// is only emitted if the order actually changes.
+//
+// less is called with the menu locked, so it must not modify the
+// menu.
+func (menu *Menu) SortChildren(less func(a, b *MenuItem) bool) error {
+	defer menu.lock()()
+	return sortChildren(menu, menu, less)
 }
 
-func (item *MenuItem) emitPropertiesUpdated(props iter.Seq[string]) error {
-	type prop struct {
-		Name  string
-		Value any
-	}
+// SortChildren sorts the children of item using less, which reports
+// whether a should come before b. The sort is stable. A layout update
+// is only emitted if the order actually changes.
+//
+// less is called with the menu locked, so it must not modify the
+// menu.
+func (item *MenuItem) SortChildren(less func(a, b *MenuItem) bool) error {
+	defer item.menu.lock()()
+	return sortChildren(item.menu, item, less)
+}
 
-	type updatedProps struct {
-		ID    int
-		Props []prop
+func (item *MenuItem) applyProps(props []MenuItemProp) (updated, removed iter.Seq[string], errs []error) {
+	w := menuItemProps{
+		MenuItem: item,
+		dirty:    make(set.Set[string]),
+		removed:  make(set.Set[string]),
 	}
-
-	type removedProps struct {
-		ID    int
-		Props []string
+	for _, p := range props {
+		p(&w)
 	}
+	return maps.Keys(w.dirty), maps.Keys(w.removed), w.errs
+}
 
-	var updated []prop
+func (item *MenuItem) emitPropertiesUpdated(props, removedProps iter.Seq[string]) error {
+	updated := make(map[string]any)
 	for change := range props {
-		updated = append(updated, prop{
-			Name:  change,
-			Value: item.props[change],
-		})
+		v, ok := item.props[change]
+		if ok {
+			updated[change] = v
+		}
 	}
 
-	item.menu.dirty.Add(item.parent)
+	var removed []menuRemovedProps
+	names := slices.Sorted(removedProps)
+	if len(names) != 0 {
+		removed = []menuRemovedProps{{ID: item.id, Properties: names}}
+	}
 
-	return item.menu.item.conn.Emit(
-		menuPath,
-		"com.canonical.dbusmenu.ItemsPropertiesUpdated",
-		[]updatedProps{{
-			ID:    item.id,
-			Props: updated,
-		}},
-		[]removedProps(nil),
+	item.menu.dirty.mark(item.parent)
+
+	return item.menu.emitItemsPropertiesUpdated(
+		[]menuProps{{ID: item.id, Properties: updated}},
+		removed,
 	)
 }
 
@@ -225,6 +505,13 @@ func (item *MenuItem) emitPropertiesUpdated(props iter.Seq[string]) error {
 // activated. What exactly this means is dependent on the environment
 // and situation.
 func (item *MenuItem) RequestActivation(timestamp uint32) error {
+	item.menu.m.RLock()
+	err := item.checkAttached()
+	item.menu.m.RUnlock()
+	if err != nil {
+		return err
+	}
+
 	return item.menu.item.conn.Emit(
 		menuPath,
 		"com.canonical.dbusmenu.ItemActivationRequested",
@@ -243,7 +530,9 @@ func (item *MenuItem) Type() MenuType {
 
 // Label returns the current value of the item's "label" property.
 // This is the text that should be displayed by the environment for
-// the item in the menu.
+// the item in the menu. It is returned as is, with any underscores
+// marking the mnemonic intact. See [MenuItem.DisplayLabel] for the
+// text as it is actually displayed.
 func (item *MenuItem) Label() string {
 	item.m.RLock()
 	defer item.m.RUnlock()
@@ -300,6 +589,10 @@ func (item *MenuItem) IconData() (image.Image, error) {
 //	[][]string{
 //		[]string{"Control", "Alt", "e"},
 //	}
+//
+// Each inner slice is a single chord consisting of any number of the
+// modifiers "Control", "Alt", "Shift", and "Super" followed by a key.
+// See [MenuItem.KeyShortcut] for a typed version.
 func (item *MenuItem) Shortcut() [][]string {
 	item.m.RLock()
 	defer item.m.RUnlock()
@@ -307,6 +600,16 @@ func (item *MenuItem) Shortcut() [][]string {
 	return mapLookup(item.props, "shortcut", [][]string(nil))
 }
 
+// KeyShortcut is like [MenuItem.Shortcut] but returns the shortcut as
+// a [Shortcut]. It returns nil if the item has no shortcut.
+func (item *MenuItem) KeyShortcut() Shortcut {
+	shortcut := item.Shortcut()
+	if len(shortcut) == 0 {
+		return nil
+	}
+	return shortcutFromWire(shortcut)
+}
+
 // ToggleType returns the current value of the item's "toggle-type"
 // property.
 func (item *MenuItem) ToggleType() MenuToggleType {
@@ -316,6 +619,27 @@ func (item *MenuItem) ToggleType() MenuToggleType {
 	return mapLookup(item.props, "toggle-type", MenuToggleType(""))
 }
 
+// Disposition returns the current value of the item's "disposition"
+// property. This is how the environment should present the item, such
+// as emphasizing it as a warning.
+func (item *MenuItem) Disposition() MenuDisposition {
+	item.m.RLock()
+	defer item.m.RUnlock()
+
+	return mapLookup(item.props, "disposition", DispositionNormal)
+}
+
+// AccessibleDesc returns the current value of the item's
+// "accessible-desc" property. This is a description of the item for
+// use by screen readers and other accessibility tools. If it is empty,
+// they use the label instead.
+func (item *MenuItem) AccessibleDesc() string {
+	item.m.RLock()
+	defer item.m.RUnlock()
+
+	return mapLookup(item.props, "accessible-desc", "")
+}
+
 // ToggleState returns the current value of the item's "toggle-state"
 // property.
 func (item *MenuItem) ToggleState() MenuToggleState {
@@ -338,13 +662,21 @@ func (item *MenuItem) VendorProp(vendor, prop string) (any, bool) {
 
 // SetProps sets all of the given properties on the item.
 func (item *MenuItem) SetProps(props ...MenuItemProp) error {
-	defer item.lock()()
+	defer item.menu.lock()()
+	return item.setProps(props)
+}
+
+// setProps sets the given properties on item. The menu must be locked.
+func (item *MenuItem) setProps(props []MenuItemProp) error {
+	if err := item.checkAttached(); err != nil {
+		return err
+	}
 	parent := item.getParent()
 
-	defer item.menu.lock()()
+	defer item.lock()()
 
-	dirty, errs := item.applyProps(props)
-	errs = append(errs, item.emitPropertiesUpdated(dirty))
+	dirty, removed, errs := item.applyProps(props)
+	errs = append(errs, item.emitPropertiesUpdated(dirty, removed))
 	errs = append(errs, item.menu.updateLayout(parent))
 
 	return errors.Join(errs...)
@@ -368,6 +700,17 @@ const (
 	Radio         MenuToggleType = "radio"
 )
 
+// MenuDisposition is the possible values of how a menu item should be
+// presented to the user.
+type MenuDisposition string
+
+const (
+	DispositionNormal      MenuDisposition = "normal"
+	DispositionInformative MenuDisposition = "informative"
+	DispositionWarning     MenuDisposition = "warning"
+	DispositionAlert       MenuDisposition = "alert"
+)
+
 // MenuToggleState is the two main states that a togglable menu item can be in. All values other than [On] and [Off] are considered to be indeterminate.
 type MenuToggleState int
 
@@ -385,18 +728,49 @@ type MenuItemProp func(*menuItemProps)
 
 type menuItemProps struct {
 	*MenuItem
-	dirty set.Set[string]
-	errs  []error
+	dirty   set.Set[string]
+	removed set.Set[string]
+	errs    []error
 }
 
 func (item *menuItemProps) mark(change string) {
 	item.dirty.Add(change)
+	item.removed.Remove(change)
+}
+
+// unset deletes a property so that the environment falls back to its
+// default value for it.
+func (item *menuItemProps) unset(prop string) {
+	_, ok := item.props[prop]
+	if !ok {
+		return
+	}
+
+	delete(item.props, prop)
+	item.dirty.Remove(prop)
+	item.removed.Add(prop)
 }
 
 func (item *menuItemProps) catch(err error) {
 	item.errs = append(item.errs, err)
 }
 
+// checkRadio reports whether the toggle properties of item can be set
+// to the given values, which is not the case if the item belongs to a
+// RadioGroup and they differ from its current ones.
+func (item *menuItemProps) checkRadio(props map[string]any) bool {
+	if item.radio == nil {
+		return true
+	}
+	for k, v := range props {
+		if item.props[k] != v {
+			item.catch(ErrRadioMember)
+			return false
+		}
+	}
+	return true
+}
+
 // MenuItemType sets a MenuItem's "type" property. See
 // [MenuItem.Type].
 func MenuItemType(t MenuType) MenuItemProp {
@@ -406,7 +780,8 @@ func MenuItemType(t MenuType) MenuItemProp {
 }
 
 // MenuItemLabel sets a MenuItem's "label" property. See
-// [MenuItem.Label].
+// [MenuItem.Label]. Underscores in label mark the mnemonic. To display
+// text literally, use [MenuItemLabelLiteral] instead.
 func MenuItemLabel(label string) MenuItemProp {
 	return func(item *menuItemProps) {
 		item.props["label"] = label
@@ -433,67 +808,192 @@ func MenuItemVisible(visible bool) MenuItemProp {
 }
 
 // MenuItemIconName sets a MenuItem's "icon-name" property. See
-// [MenuItem.IconName].
+// [MenuItem.IconName]. An empty name removes the property.
 func MenuItemIconName(name string) MenuItemProp {
 	return func(item *menuItemProps) {
+		if name == "" {
+			item.unset("icon-name")
+			return
+		}
+
 		item.props["icon-name"] = name
 		item.mark("icon-name")
 	}
 }
 
 // MenuItemIconData sets a MenuItem's "icon-data" property. See
-// [MenuItem.IconData].
+// [MenuItem.IconData]. A nil image removes the property.
+//
+// The image is encoded as a PNG. Encodings are cached by the menu
+// based on the content of the image, so setting the same image on
+// multiple items or multiple times is cheap. A [Pixmap] is handled
+// more efficiently than other image types. See also
+// [ItemMenuIconSize].
 func MenuItemIconData(img image.Image) MenuItemProp {
 	return func(item *menuItemProps) {
-		var buf bytes.Buffer
-		err := png.Encode(&buf, img)
+		if img == nil {
+			item.unset("icon-data")
+			return
+		}
+
+		data, err := item.menu.encodeIcon(img)
 		if err != nil {
 			item.catch(err)
 			return
 		}
 
-		item.props["icon-data"] = buf.Bytes()
+		item.props["icon-data"] = data
+		item.mark("icon-data")
+	}
+}
+
+// MenuItemIconPNG is like [MenuItemIconData] but takes an already
+// PNG-encoded image, which is sent as is unless it needs to be
+// scaled. An empty slice removes the property.
+func MenuItemIconPNG(data []byte) MenuItemProp {
+	return func(item *menuItemProps) {
+		if len(data) == 0 {
+			item.unset("icon-data")
+			return
+		}
+
+		data, err := item.menu.encodedIcon(data)
+		if err != nil {
+			item.catch(err)
+			return
+		}
+
+		item.props["icon-data"] = data
 		item.mark("icon-data")
 	}
 }
 
 // MenuItemShortcut sets a MenuItem's "shortcut" property. See
-// [MenuItem.Shortcut].
+// [MenuItem.Shortcut]. An empty shortcut removes the property.
 func MenuItemShortcut(shortcut [][]string) MenuItemProp {
 	return func(item *menuItemProps) {
+		if len(shortcut) == 0 {
+			item.unset("shortcut")
+			return
+		}
+
 		item.props["shortcut"] = shortcut
 		item.mark("shortcut")
 	}
 }
 
+// MenuItemKeyShortcut is like [MenuItemShortcut] but takes a
+// [Shortcut], such as one returned by [ParseShortcut]. If the
+// shortcut is not valid according to [Shortcut.Validate], the
+// property is left unchanged and an error is returned.
+func MenuItemKeyShortcut(shortcut Shortcut) MenuItemProp {
+	if len(shortcut) == 0 {
+		return MenuItemShortcut(nil)
+	}
+	return func(item *menuItemProps) {
+		err := shortcut.Validate()
+		if err != nil {
+			item.catch(fmt.Errorf("invalid shortcut: %w", err))
+			return
+		}
+		MenuItemShortcut(shortcut.wire())(item)
+	}
+}
+
 // MenuItemToggleType sets a MenuItem's "toggle-type" property. See
-// [MenuItem.ToggleType].
+// [MenuItem.ToggleType]. Setting it to [NonToggleable] removes the
+// property.
 func MenuItemToggleType(t MenuToggleType) MenuItemProp {
 	return func(item *menuItemProps) {
+		if !item.checkRadio(map[string]any{"toggle-type": t}) {
+			return
+		}
+		if t == NonToggleable {
+			item.unset("toggle-type")
+			return
+		}
+
 		item.props["toggle-type"] = t
 		item.mark("toggle-type")
 	}
 }
 
+// MenuItemDisposition sets a MenuItem's "disposition" property. See
+// [MenuItem.Disposition]. Setting it to [DispositionNormal] removes
+// the property.
+func MenuItemDisposition(d MenuDisposition) MenuItemProp {
+	return func(item *menuItemProps) {
+		if d == DispositionNormal {
+			item.unset("disposition")
+			return
+		}
+
+		item.props["disposition"] = d
+		item.mark("disposition")
+	}
+}
+
+// MenuItemAccessibleDesc sets a MenuItem's "accessible-desc"
+// property. See [MenuItem.AccessibleDesc]. An empty description
+// removes the property.
+func MenuItemAccessibleDesc(desc string) MenuItemProp {
+	return func(item *menuItemProps) {
+		if desc == "" {
+			item.unset("accessible-desc")
+			return
+		}
+
+		item.props["accessible-desc"] = desc
+		item.mark("accessible-desc")
+	}
+}
+
 // MenuItemToggleState sets a MenuItem's "toggle-state" property. See
 // [MenuItem.ToggleState].
 func MenuItemToggleState(state MenuToggleState) MenuItemProp {
 	return func(item *menuItemProps) {
+		if !item.checkRadio(map[string]any{"toggle-state": state}) {
+			return
+		}
 		item.props["toggle-state"] = state
 		item.mark("toggle-state")
 	}
 }
 
 // MenuItemVendorProp sets a vendor-specific custom property with the
-// given vendor and property name. See [MenuItem.VendorProp].
+// given vendor and property name. See [MenuItem.VendorProp]. A nil
+// value removes the property.
 func MenuItemVendorProp(vendor, prop string, value any) MenuItemProp {
 	return func(item *menuItemProps) {
 		name := vendorPropName(vendor, prop)
+		if value == nil {
+			item.unset(name)
+			return
+		}
+
 		item.props[name] = value
 		item.mark(name)
 	}
 }
 
+// MenuItemUnset removes the property with the given name from a
+// MenuItem, causing the environment to fall back to its default value
+// for it. The removal is reported to the environment along with any
+// other property changes. The "children-display" property is managed
+// automatically and can not be removed. To remove vendor-specific
+// properties, see [MenuItemVendorProp].
+func MenuItemUnset(prop string) MenuItemProp {
+	return func(item *menuItemProps) {
+		if prop == "children-display" {
+			return
+		}
+		if (prop == "toggle-type" || prop == "toggle-state") && !item.checkRadio(map[string]any{prop: nil}) {
+			return
+		}
+		item.unset(prop)
+	}
+}
+
 // MenuItemHandler sets the event handler for a MenuItem.
 func MenuItemHandler(handler MenuEventHandler) MenuItemProp {
 	return func(item *menuItemProps) {
@@ -501,23 +1001,62 @@ func MenuItemHandler(handler MenuEventHandler) MenuItemProp {
 	}
 }
 
-func vendorPropName(vendor, prop string) string {
-	return fmt.Sprintf("x-%v-%v", vendor, prop)
-}
+// MenuItemOnEvent is like [MenuItemHandler] but sets a handler that is
+// given the MenuItem that it is set on. A nil handler removes the
+// existing one.
+func MenuItemOnEvent(handler MenuItemEventHandler) MenuItemProp {
+	return func(item *menuItemProps) {
+		if handler == nil {
+			item.handler = nil
+			return
+		}
 
-func (item *MenuItem) getParent() menuNode {
-	if item.parent == 0 {
-		return item.menu
+		self := item.MenuItem
+		item.handler = func(eventID MenuEventID, data any, timestamp uint32) error {
+			return handler(self, eventID, data, timestamp)
+		}
 	}
+}
 
-	item.menu.m.RLock()
-	defer item.menu.m.RUnlock()
+// MenuItemSubmenu sets whether or not a MenuItem is always presented
+// as a sub-menu, even if it has no children. Normally, an item is
+// only presented as a sub-menu while it has children. This is useful
+// for sub-menus whose children are populated lazily. See
+// [MenuItemOnAboutToShow].
+func MenuItemSubmenu(submenu bool) MenuItemProp {
+	return func(item *menuItemProps) {
+		item.submenu = submenu
+		item.updateChildrenDisplay()
+		item.mark("children-display")
+	}
+}
 
-	p, ok := item.menu.nodes[item.parent]
-	if !ok {
-		return nil
+// MenuItemOnAboutToShow sets a function that is called when the
+// environment is about to show the item's children, either via the
+// AboutToShow and AboutToShowGroup methods or via an [Opened] event.
+// The function can rebuild the item's children, after which the
+// environment is told that the item needs to be updated. As some
+// environments trigger more than one of those, the function may be
+// called more than once for the same showing of the menu.
+//
+// For the AboutToShow and AboutToShowGroup methods, the function is
+// called synchronously regardless of [DispatchMode], as the result
+// needs to be reported back to the environment. For an Opened event,
+// it is dispatched along with the event, just before the event is
+// delivered, so with [DispatchAsync] it stays in order with other
+// events. Setting this also marks the item as a sub-menu as if by
+// [MenuItemSubmenu].
+func MenuItemOnAboutToShow(f func(item *MenuItem) error) MenuItemProp {
+	return func(item *menuItemProps) {
+		item.aboutToShow = f
+		if f != nil {
+			MenuItemSubmenu(true)(item)
+		}
 	}
-	return p
+}
+
+func vendorPropName(vendor, prop string) string {
+	return fmt.Sprintf("x-%v-%v", vendor, prop)
 }
 
 func (item *MenuItem) lock() func() {
@@ -535,7 +1074,11 @@ func (item *MenuItem) getChildren() []int {
 
 func (item *MenuItem) setChildren(c []int) {
 	item.children = c
-	if len(item.children) == 0 {
+	item.updateChildrenDisplay()
+}
+
+func (item *MenuItem) updateChildrenDisplay() {
+	if len(item.children) == 0 && !item.submenu {
 		item.props["children-display"] = ""
 		return
 	}
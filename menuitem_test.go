@@ -0,0 +1,124 @@
+package tray
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestAddChildSignals(t *testing.T) {
+	tests := []struct {
+		name    string
+		parent  string
+		props   []MenuItemProp
+		signals []string
+	}{
+		{
+			name:    "TopLevel",
+			props:   []MenuItemProp{MenuItemLabel("b")},
+			signals: []string{"layout root", `props b.label="b"`},
+		},
+		{
+			name:    "Nested",
+			parent:  "a",
+			props:   []MenuItemProp{MenuItemLabel("b"), MenuItemEnabled(false)},
+			signals: []string{"layout a", `props b.enabled=false b.label="b"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+			a, err := item.menu.AddChild(MenuItemLabel("a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			bus.signals(t)
+
+			if test.parent == "" {
+				_, err = item.menu.AddChild(test.props...)
+			} else {
+				_, err = a.AddChild(test.props...)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if sigs := bus.menuSignals(t, item.menu, itemLabel); !slices.Equal(sigs, test.signals) {
+				t.Errorf("signals = %q; want %q", sigs, test.signals)
+			}
+		})
+	}
+}
+
+func TestAboutToShowOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		mode DispatchMode
+	}{
+		{name: "Sync", mode: DispatchSync},
+		{name: "Async", mode: DispatchAsync},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, _ := newTestItem(t)
+			item.SetProps(ItemDispatchMode(test.mode))
+
+			var m sync.Mutex
+			var calls []string
+			record := func(call string) {
+				m.Lock()
+				defer m.Unlock()
+				calls = append(calls, call)
+			}
+
+			done := make(chan struct{})
+			x, _ := item.menu.AddChild(MenuItemHandler(func(MenuEventID, any, uint32) error {
+				// Give a hook that doesn't wait its turn a chance to run
+				// first.
+				if test.mode == DispatchAsync {
+					time.Sleep(20 * time.Millisecond)
+				}
+				record("click x")
+				return nil
+			}))
+			a, _ := item.menu.AddChild(
+				MenuItemOnAboutToShow(func(*MenuItem) error {
+					record("hook a")
+					return nil
+				}),
+				MenuItemHandler(func(MenuEventID, any, uint32) error {
+					record("opened a")
+					return nil
+				}),
+			)
+			y, _ := item.menu.AddChild(MenuItemHandler(func(MenuEventID, any, uint32) error {
+				record("click y")
+				close(done)
+				return nil
+			}))
+
+			menu := (*dbusmenu)(item.menu)
+			menu.event(x.id, Clicked, dbus.MakeVariant(""), 0)
+			menu.event(a.id, Opened, dbus.MakeVariant(""), 0)
+			menu.event(y.id, Clicked, dbus.MakeVariant(""), 0)
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("events were not delivered")
+			}
+
+			m.Lock()
+			defer m.Unlock()
+			want := []string{"click x", "hook a", "opened a", "click y"}
+			if !slices.Equal(calls, want) {
+				t.Errorf("calls = %q; want %q", calls, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package tray
+
+import (
+	"runtime/debug"
+	"slices"
+	"time"
+)
+
+// Middleware wraps the invocation of handlers. It is called with the
+// event that is being handled and a function that continues the
+// chain, eventually calling the actual handler. A Middleware can do
+// work before and after calling next, change the returned error, or
+// not call next at all to prevent the handler from running.
+//
+// Middleware applies to both the item's [Handler] and to all
+// [MenuEventHandler] functions of its menu. The event passed to it is
+// one of the same types that are sent via [Item.Events], such as
+// [ActivateEvent] or [MenuEvent]. Middleware is only called if there
+// is a handler to call.
+type Middleware func(ev Event, next func() error) error
+
+// Recover is a Middleware that recovers from panics in the rest of
+// the chain and converts them into a [*PanicError]. By default, it is
+// automatically applied outside of all other middleware. See
+// [ItemRecoverPanics].
+func Recover(ev Event, next func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			logger.Error("panic in handler", "event", ev, "err", r)
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return next()
+}
+
+// LogEvents is a Middleware that logs every handled event along with
+// how long it took to handle and the resulting error, if any. The
+// logging uses the same logger as the rest of the package, so it is
+// only visible when the TRAY_DEBUG environment variable is set to 1.
+func LogEvents(ev Event, next func() error) error {
+	start := time.Now()
+	err := next()
+	logger.Info("handled event", "event", ev, "duration", time.Since(start), "err", err)
+	return err
+}
+
+// TimeEvents returns a Middleware that calls report with the amount of
+// time that it took to handle each event and the resulting error.
+func TimeEvents(report func(ev Event, duration time.Duration, err error)) Middleware {
+	return func(ev Event, next func() error) error {
+		start := time.Now()
+		err := next()
+		report(ev, time.Since(start), err)
+		return err
+	}
+}
+
+func (item *Item) invoke(ev Event, call func() error) error {
+	next := call
+	if mw := item.middleware.Load(); mw != nil {
+		for _, m := range slices.Backward(*mw) {
+			n := next
+			next = func() error { return m(ev, n) }
+		}
+	}
+
+	if !item.noRecover.Load() {
+		return Recover(ev, next)
+	}
+	return next()
+}
+
+// ItemMiddleware sets the middleware chain that wraps all handler
+// invocations for the item and its menu, replacing any previously set
+// chain. The first Middleware given is the outermost one.
+func ItemMiddleware(middleware ...Middleware) ItemProp {
+	return func(item *itemProps) {
+		chain := slices.DeleteFunc(slices.Clone(middleware), func(m Middleware) bool { return m == nil })
+		item.middleware.Store(&chain)
+	}
+}
+
+// ItemRecoverPanics sets whether or not panics in handlers are
+// recovered from and converted into errors. If a panic is not
+// recovered from, it will crash the entire process. The default is
+// true.
+func ItemRecoverPanics(enabled bool) ItemProp {
+	return func(item *itemProps) {
+		item.noRecover.Store(!enabled)
+	}
+}
@@ -0,0 +1,169 @@
+package tray
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMenuMove(t *testing.T) {
+	byLabel := func(a, b *MenuItem) bool { return itemLabel(a) < itemLabel(b) }
+	reversed := func(a, b *MenuItem) bool { return itemLabel(a) > itemLabel(b) }
+
+	tests := []struct {
+		name    string
+		op      func(menu *Menu, items map[string]*MenuItem) error
+		err     error
+		layout  string
+		signals []string
+	}{
+		{
+			name: "InsertChild",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				_, err := menu.InsertChild(1, MenuItemLabel("x"))
+				return err
+			},
+			layout:  "a(d) x b c",
+			signals: []string{"layout root", `props x.label="x"`},
+		},
+		{
+			name: "InsertChildPastEnd",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				_, err := items["a"].InsertChild(10, MenuItemLabel("x"))
+				return err
+			},
+			layout:  "a(d x) b c",
+			signals: []string{"layout a", `props x.label="x"`},
+		},
+		{
+			name: "AddChildBefore",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				_, err := menu.AddChildBefore(items["b"], MenuItemLabel("x"))
+				return err
+			},
+			layout:  "a(d) x b c",
+			signals: []string{"layout root", `props x.label="x"`},
+		},
+		{
+			name: "AddChildAfter",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				_, err := items["a"].AddChildAfter(items["d"], MenuItemLabel("x"))
+				return err
+			},
+			layout:  "a(d x) b c",
+			signals: []string{"layout a", `props x.label="x"`},
+		},
+		{
+			name: "AppendChild",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return menu.AppendChild(items["d"])
+			},
+			layout:  "a b c d",
+			signals: []string{"layout root", "layout a"},
+		},
+		{
+			name: "AppendChildNested",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["a"].AppendChild(items["b"])
+			},
+			layout:  "a(d b) c",
+			signals: []string{"layout a", "layout root"},
+		},
+		{
+			name: "MoveToIndex",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return menu.MoveToIndex(items["c"], 0)
+			},
+			layout:  "c a(d) b",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "MoveBefore",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["c"].MoveBefore(items["d"])
+			},
+			layout:  "a(c d) b",
+			signals: []string{"layout a", "layout root"},
+		},
+		{
+			name: "MoveAfter",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["a"].MoveAfter(items["c"])
+			},
+			layout:  "b c a(d)",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "MoveBeforeSelf",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["b"].MoveBefore(items["b"])
+			},
+			layout: "a(d) b c",
+		},
+		{
+			name: "AppendToSelf",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["a"].AppendChild(items["a"])
+			},
+			err:    ErrMoveIntoSelf,
+			layout: "a(d) b c",
+		},
+		{
+			name: "AppendToDescendant",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["d"].AppendChild(items["a"])
+			},
+			err:    ErrMoveIntoSelf,
+			layout: "a(d) b c",
+		},
+		{
+			name: "MoveBeforeChild",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["a"].MoveBefore(items["d"])
+			},
+			err:    ErrMoveIntoSelf,
+			layout: "a(d) b c",
+		},
+		{
+			name: "SortChildren",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return menu.SortChildren(reversed)
+			},
+			layout:  "c b a(d)",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "SortChildrenUnchanged",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return menu.SortChildren(byLabel)
+			},
+			layout: "a(d) b c",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+			menu := item.menu
+
+			items := make(map[string]*MenuItem)
+			for _, label := range []string{"a", "b", "c"} {
+				items[label], _ = menu.AddChild(MenuItemLabel(label))
+			}
+			items["d"], _ = items["a"].AddChild(MenuItemLabel("d"))
+			bus.signals(t)
+
+			err := test.op(menu, items)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v; want %v", err, test.err)
+			}
+
+			if layout := renderMenu(menu, itemLabel); layout != test.layout {
+				t.Errorf("layout = %q; want %q", layout, test.layout)
+			}
+			if sigs := bus.menuSignals(t, menu, itemLabel); !slices.Equal(sigs, test.signals) {
+				t.Errorf("signals = %q; want %q", sigs, test.signals)
+			}
+		})
+	}
+}
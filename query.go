@@ -0,0 +1,155 @@
+package tray
+
+import (
+	"iter"
+	"slices"
+)
+
+// ID returns the item's ID. IDs are unique within a menu and are what
+// the environment uses to refer to items.
+func (item *MenuItem) ID() int {
+	return item.id
+}
+
+// Menu returns the Menu that item belongs to.
+func (item *MenuItem) Menu() *Menu {
+	return item.menu
+}
+
+// snapshot returns the items with the given IDs and, if recursive is
+// true, all of their descendants in display order. The menu must be
+// at least read locked.
+func (menu *Menu) snapshot(ids []int, recursive bool) []*MenuItem {
+	items := make([]*MenuItem, 0, len(ids))
+	for _, id := range ids {
+		item := menu.nodes[id]
+		if item == nil {
+			continue
+		}
+		items = append(items, item)
+
+		if recursive {
+			item.m.RLock()
+			children := item.children
+			item.m.RUnlock()
+
+			items = append(items, menu.snapshot(children, true)...)
+		}
+	}
+	return items
+}
+
+// Children returns the top-level items of the menu in display order.
+func (menu *Menu) Children() []*MenuItem {
+	menu.m.RLock()
+	defer menu.m.RUnlock()
+
+	return menu.snapshot(menu.children, false)
+}
+
+// Children returns the children of item in display order.
+func (item *MenuItem) Children() []*MenuItem {
+	item.menu.m.RLock()
+	defer item.menu.m.RUnlock()
+
+	item.m.RLock()
+	children := item.children
+	item.m.RUnlock()
+
+	return item.menu.snapshot(children, false)
+}
+
+// Parent returns the parent of item. It returns nil if item is a
+// top-level item or if it is not currently part of the menu.
+func (item *MenuItem) Parent() *MenuItem {
+	item.menu.m.RLock()
+	defer item.menu.m.RUnlock()
+
+	if item.checkAttached() != nil {
+		return nil
+	}
+
+	item.m.RLock()
+	parent := item.parent
+	item.m.RUnlock()
+
+	if parent == 0 {
+		return nil
+	}
+	return item.menu.nodes[parent]
+}
+
+// Walk returns an iterator over every item in the menu in display
+// order, with each item being followed by all of its descendants. The
+// iterator works on a snapshot of the menu taken when iteration
+// starts, so it is safe to modify the menu during iteration.
+func (menu *Menu) Walk() iter.Seq[*MenuItem] {
+	return func(yield func(*MenuItem) bool) {
+		menu.m.RLock()
+		items := menu.snapshot(menu.children, true)
+		menu.m.RUnlock()
+
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Walk is like [Menu.Walk] but only iterates over the descendants of
+// item. It does not include item itself.
+func (item *MenuItem) Walk() iter.Seq[*MenuItem] {
+	return func(yield func(*MenuItem) bool) {
+		item.menu.m.RLock()
+		item.m.RLock()
+		children := item.children
+		item.m.RUnlock()
+		items := item.menu.snapshot(children, true)
+		item.menu.m.RUnlock()
+
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// ItemByID returns the item with the given ID, or nil if there is no
+// such item in the menu.
+func (menu *Menu) ItemByID(id int) *MenuItem {
+	menu.m.RLock()
+	defer menu.m.RUnlock()
+
+	return menu.nodes[id]
+}
+
+// Find returns the first item in display order for which match
+// returns true, or nil if there is no such item. See [Menu.Walk].
+func (menu *Menu) Find(match func(*MenuItem) bool) *MenuItem {
+	for item := range menu.Walk() {
+		if match(item) {
+			return item
+		}
+	}
+	return nil
+}
+
+// FindAll returns all items in display order for which match returns
+// true.
+func (menu *Menu) FindAll(match func(*MenuItem) bool) []*MenuItem {
+	return slices.Collect(func(yield func(*MenuItem) bool) {
+		for item := range menu.Walk() {
+			if match(item) && !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// FindByLabel returns the first item in display order whose label is
+// exactly label, or nil if there is no such item.
+func (menu *Menu) FindByLabel(label string) *MenuItem {
+	return menu.Find(func(item *MenuItem) bool { return item.Label() == label })
+}
@@ -0,0 +1,193 @@
+package tray
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrDifferentMenus is returned when attempting to group together
+// items that belong to different menus.
+var ErrDifferentMenus = errors.New("items belong to different menus")
+
+// ErrRadioMember is returned when attempting to change the toggle
+// state or type of an item that belongs to a [RadioGroup] other than
+// through the group, or to add it to a second group.
+var ErrRadioMember = errors.New("menu item's toggle state is managed by a radio group")
+
+// RadioGroup is a set of radio menu items of which at most one is
+// selected at a time. Items added to the group are converted into
+// radio items. Clicking on one of them selects it and deselects all of
+// the others before the item's own event handler, if any, is called.
+// This keeps working if the handler is replaced later. The selection
+// is made within the item's middleware and panic recovery, and an
+// error from it does not prevent the handler from being called.
+// Whenever the selection changes, the toggle states of all affected
+// items are updated with a single ItemsPropertiesUpdated signal.
+//
+// The group has sole control over the toggle states of its items.
+// Attempting to change the "toggle-state" or "toggle-type" property
+// of an item in the group by any other means, such as with
+// [MenuItemToggleState], [MenuItem.SetChecked], or a [MenuSpec]
+// passed to [Menu.Reconcile], leaves it unchanged and results in
+// [ErrRadioMember]. Use [RadioGroup.Select] instead.
+type RadioGroup struct {
+	m        sync.Mutex
+	menu     *Menu
+	items    []*MenuItem
+	selected *MenuItem
+	onChange func(selected *MenuItem) error
+}
+
+// NewRadioGroup creates a new RadioGroup containing the given items.
+// If any of them are already toggled on, the first of those becomes
+// the selected item. Otherwise, no item is selected.
+func NewRadioGroup(items ...*MenuItem) (*RadioGroup, error) {
+	var group RadioGroup
+	err := group.Add(items...)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// Add adds items to the group. If no item in the group is currently
+// selected, the first of the new items that is already toggled on
+// becomes the selected item. All of the items must belong to the
+// same menu and none of them may belong to another group.
+func (g *RadioGroup) Add(items ...*MenuItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	for _, item := range items {
+		if g.menu == nil {
+			g.menu = item.menu
+		}
+		if item.menu != g.menu {
+			return ErrDifferentMenus
+		}
+	}
+
+	defer g.menu.lock()()
+
+	for _, item := range items {
+		item.m.RLock()
+		radio := item.radio
+		item.m.RUnlock()
+		if radio != nil && radio != g {
+			return ErrRadioMember
+		}
+	}
+
+	updated := make([]menuProps, 0, len(items))
+	for _, item := range items {
+		if slices.Contains(g.items, item) {
+			continue
+		}
+		g.items = append(g.items, item)
+
+		item.m.Lock()
+		state := mapLookup(item.props, "toggle-state", Off)
+		if state != On || g.selected != nil {
+			state = Off
+		}
+		if state == On {
+			g.selected = item
+		}
+
+		item.props["toggle-type"] = Radio
+		item.props["toggle-state"] = state
+		item.radio = g
+		updated = append(updated, menuProps{
+			ID:         item.id,
+			Properties: map[string]any{"toggle-type": Radio, "toggle-state": state},
+		})
+		g.menu.dirty.mark(item.parent)
+		item.m.Unlock()
+	}
+
+	if len(updated) == 0 {
+		return nil
+	}
+	return g.menu.emitItemsPropertiesUpdated(updated, nil)
+}
+
+// Items returns the items in the group in the order that they were
+// added.
+func (g *RadioGroup) Items() []*MenuItem {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	return slices.Clone(g.items)
+}
+
+// Selected returns the currently selected item, or nil if no item is
+// selected.
+func (g *RadioGroup) Selected() *MenuItem {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	return g.selected
+}
+
+// OnChange sets a function that is called after the selection changes
+// with the newly selected item. The selected item is nil if the
+// selection was cleared.
+func (g *RadioGroup) OnChange(f func(selected *MenuItem) error) {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	g.onChange = f
+}
+
+// Select makes item the selected item, toggling it on and all other
+// items in the group off. If item is nil, all items are toggled off.
+// If item is not nil and not in the group, Select does nothing.
+func (g *RadioGroup) Select(item *MenuItem) error {
+	g.m.Lock()
+	if item == g.selected || (item != nil && !slices.Contains(g.items, item)) {
+		g.m.Unlock()
+		return nil
+	}
+	g.selected = item
+	onChange := g.onChange
+	err := g.update()
+	g.m.Unlock()
+
+	if onChange != nil {
+		err = errors.Join(err, onChange(item))
+	}
+	return err
+}
+
+func (g *RadioGroup) update() error {
+	defer g.menu.lock()()
+
+	var updated []menuProps
+	for _, item := range g.items {
+		state := Off
+		if item == g.selected {
+			state = On
+		}
+
+		item.m.Lock()
+		if mapLookup(item.props, "toggle-state", Off) != state {
+			item.props["toggle-state"] = state
+			updated = append(updated, menuProps{
+				ID:         item.id,
+				Properties: map[string]any{"toggle-state": state},
+			})
+			g.menu.dirty.mark(item.parent)
+		}
+		item.m.Unlock()
+	}
+
+	if len(updated) == 0 {
+		return nil
+	}
+	return g.menu.emitItemsPropertiesUpdated(updated, nil)
+}
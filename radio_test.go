@@ -0,0 +1,382 @@
+package tray
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// click sends a Clicked event for item as if it came from the host.
+func click(item *MenuItem) error {
+	return (*dbusmenu)(item.menu).event(item.id, Clicked, dbus.MakeVariant(""), 0)
+}
+
+// toggleStates renders the toggle states of items, such as "a+ b- c-".
+func toggleStates(items []*MenuItem) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		item.m.RLock()
+		state := "?"
+		switch item.props["toggle-state"] {
+		case On:
+			state = "+"
+		case Off:
+			state = "-"
+		}
+		parts = append(parts, itemLabel(item)+state)
+		item.m.RUnlock()
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestRadioGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []MenuToggleState
+		steps   []string
+		states  string
+		changes []string
+		signals []string
+	}{
+		{
+			name:    "NoneSelected",
+			initial: []MenuToggleState{Off, Off, Off},
+			states:  "a- b- c-",
+		},
+		{
+			name:    "FirstOnWins",
+			initial: []MenuToggleState{Off, On, On},
+			states:  "a- b+ c-",
+		},
+		{
+			name:    "Click",
+			initial: []MenuToggleState{On, Off, Off},
+			steps:   []string{"click b"},
+			states:  "a- b+ c-",
+			changes: []string{"b"},
+			signals: []string{"props a.toggle-state=0 b.toggle-state=1"},
+		},
+		{
+			name:    "ClickSelected",
+			initial: []MenuToggleState{On, Off, Off},
+			steps:   []string{"click a"},
+			states:  "a+ b- c-",
+		},
+		{
+			name:    "Select",
+			initial: []MenuToggleState{Off, Off, Off},
+			steps:   []string{"select c", "select a"},
+			states:  "a+ b- c-",
+			changes: []string{"c", "a"},
+			signals: []string{
+				"props c.toggle-state=1",
+				"props a.toggle-state=1 c.toggle-state=0",
+			},
+		},
+		{
+			name:    "Clear",
+			initial: []MenuToggleState{Off, On, Off},
+			steps:   []string{"select -"},
+			states:  "a- b- c-",
+			changes: []string{"-"},
+			signals: []string{"props b.toggle-state=0"},
+		},
+		{
+			name:    "SelectOutsider",
+			initial: []MenuToggleState{On, Off, Off},
+			steps:   []string{"select x"},
+			states:  "a+ b- c-",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+			menu := item.menu
+
+			byLabel := make(map[string]*MenuItem)
+			var items []*MenuItem
+			for i, state := range test.initial {
+				label := string(rune('a' + i))
+				item, err := menu.AddChild(MenuItemLabel(label), MenuItemToggleState(state))
+				if err != nil {
+					t.Fatal(err)
+				}
+				byLabel[label] = item
+				items = append(items, item)
+			}
+			byLabel["x"], _ = menu.AddChild(MenuItemLabel("x"))
+
+			g, err := NewRadioGroup(items...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var changes []string
+			g.OnChange(func(selected *MenuItem) error {
+				if selected == nil {
+					changes = append(changes, "-")
+					return nil
+				}
+				changes = append(changes, itemLabel(selected))
+				return nil
+			})
+			bus.signals(t)
+
+			for _, step := range test.steps {
+				action, label, _ := strings.Cut(step, " ")
+				switch action {
+				case "click":
+					err = click(byLabel[label])
+				case "select":
+					err = g.Select(byLabel[label])
+				}
+				if err != nil {
+					t.Fatalf("%v: %v", step, err)
+				}
+			}
+
+			if states := toggleStates(items); states != test.states {
+				t.Errorf("states = %q; want %q", states, test.states)
+			}
+			if got := g.Items(); !slices.Equal(got, items) {
+				t.Errorf("Items() = %v", got)
+			}
+			if !slices.Equal(changes, test.changes) {
+				t.Errorf("changes = %q; want %q", changes, test.changes)
+			}
+			if sigs := bus.menuSignals(t, menu, itemLabel); !slices.Equal(sigs, test.signals) {
+				t.Errorf("signals = %q; want %q", sigs, test.signals)
+			}
+		})
+	}
+}
+
+func TestRadioGroupKeepsHandler(t *testing.T) {
+	item, _ := newTestItem(t)
+
+	var clicked []string
+	handler := func(label string) MenuItemProp {
+		return MenuItemHandler(func(eventID MenuEventID, data any, timestamp uint32) error {
+			clicked = append(clicked, label)
+			return nil
+		})
+	}
+	a, _ := item.menu.AddChild(MenuItemLabel("a"), handler("a"))
+	b, _ := item.menu.AddChild(MenuItemLabel("b"))
+
+	g, err := NewRadioGroup(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	click(b)
+	click(a)
+
+	if g.Selected() != a {
+		t.Errorf("selected %v", g.Selected())
+	}
+	if !slices.Equal(clicked, []string{"a"}) {
+		t.Errorf("handlers called for %v", clicked)
+	}
+}
+
+func TestRadioGroupDifferentMenus(t *testing.T) {
+	item1, _ := newTestItem(t)
+	item2, _ := newTestItem(t)
+	a, _ := item1.menu.AddChild()
+	b, _ := item2.menu.AddChild()
+
+	_, err := NewRadioGroup(a, b)
+	if !errors.Is(err, ErrDifferentMenus) {
+		t.Fatalf("NewRadioGroup returned %v", err)
+	}
+}
+
+func TestRadioGroupMembership(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(menu *Menu, a *MenuItem) error
+		err    error
+		states string
+	}{
+		{
+			name: "ToggleState",
+			op: func(menu *Menu, a *MenuItem) error {
+				return a.SetProps(MenuItemToggleState(Off))
+			},
+			err:    ErrRadioMember,
+			states: "a+ b-",
+		},
+		{
+			name: "SameToggleState",
+			op: func(menu *Menu, a *MenuItem) error {
+				return a.SetProps(MenuItemToggleState(On))
+			},
+			states: "a+ b-",
+		},
+		{
+			name: "ToggleType",
+			op: func(menu *Menu, a *MenuItem) error {
+				return a.SetProps(MenuItemToggleType(Checkmark))
+			},
+			err:    ErrRadioMember,
+			states: "a+ b-",
+		},
+		{
+			name: "Unset",
+			op: func(menu *Menu, a *MenuItem) error {
+				return a.SetProps(MenuItemUnset("toggle-state"))
+			},
+			err:    ErrRadioMember,
+			states: "a+ b-",
+		},
+		{
+			name: "SetChecked",
+			op: func(menu *Menu, a *MenuItem) error {
+				return a.SetChecked(false)
+			},
+			err:    ErrRadioMember,
+			states: "a+ b-",
+		},
+		{
+			name: "Checkbox",
+			op: func(menu *Menu, a *MenuItem) error {
+				return a.SetProps(MenuItemCheckbox(Off, nil))
+			},
+			err:    ErrRadioMember,
+			states: "a+ b-",
+		},
+		{
+			name: "SecondGroup",
+			op: func(menu *Menu, a *MenuItem) error {
+				_, err := NewRadioGroup(a)
+				return err
+			},
+			err:    ErrRadioMember,
+			states: "a+ b-",
+		},
+		{
+			name: "ReplacedHandler",
+			op: func(menu *Menu, a *MenuItem) error {
+				b := menu.Children()[1]
+				err := b.SetProps(MenuItemHandler(func(MenuEventID, any, uint32) error { return nil }))
+				if err != nil {
+					return err
+				}
+				return click(b)
+			},
+			states: "a- b+",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, _ := newTestItem(t)
+			a, _ := item.menu.AddChild(MenuItemLabel("a"), MenuItemToggleState(On))
+			b, _ := item.menu.AddChild(MenuItemLabel("b"))
+			_, err := NewRadioGroup(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = test.op(item.menu, a)
+			if !errors.Is(err, test.err) {
+				t.Errorf("error = %v; want %v", err, test.err)
+			}
+			if states := toggleStates([]*MenuItem{a, b}); states != test.states {
+				t.Errorf("states = %q; want %q", states, test.states)
+			}
+		})
+	}
+}
+
+func TestRadioGroupClick(t *testing.T) {
+	errChange := errors.New("change failed")
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name     string
+		onChange func(*MenuItem) error
+		handler  error
+		errs     []error
+		panicked bool
+		handled  bool
+		seen     []string
+	}{
+		{
+			name:    "Success",
+			handled: true,
+			seen:    []string{"a-", "a+"},
+		},
+		{
+			name:     "OnChangeError",
+			onChange: func(*MenuItem) error { return errChange },
+			errs:     []error{errChange},
+			handled:  true,
+			seen:     []string{"a-", "a+"},
+		},
+		{
+			name:     "BothErrors",
+			onChange: func(*MenuItem) error { return errChange },
+			handler:  errHandler,
+			errs:     []error{errChange, errHandler},
+			handled:  true,
+			seen:     []string{"a-", "a+"},
+		},
+		{
+			name:     "OnChangePanics",
+			onChange: func(*MenuItem) error { panic("oops") },
+			panicked: true,
+			seen:     []string{"a-"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, _ := newTestItem(t)
+
+			var seen []string
+			item.SetProps(ItemMiddleware(func(ev Event, next func() error) error {
+				items := []*MenuItem{ev.(MenuEvent).Item}
+				seen = append(seen, toggleStates(items))
+				err := next()
+				seen = append(seen, toggleStates(items))
+				return err
+			}))
+
+			var handled bool
+			a, _ := item.menu.AddChild(MenuItemLabel("a"), MenuItemHandler(func(MenuEventID, any, uint32) error {
+				handled = true
+				return test.handler
+			}))
+			g, err := NewRadioGroup(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.OnChange(test.onChange)
+
+			err = click(a)
+			for _, want := range test.errs {
+				if !errors.Is(err, want) {
+					t.Errorf("error = %v; want %v", err, want)
+				}
+			}
+			var perr *PanicError
+			if errors.As(err, &perr) != test.panicked {
+				t.Errorf("error = %v; panicked = %v", err, test.panicked)
+			}
+			if len(test.errs) == 0 && !test.panicked && err != nil {
+				t.Errorf("error = %v", err)
+			}
+
+			if handled != test.handled {
+				t.Errorf("handled = %v; want %v", handled, test.handled)
+			}
+			if !slices.Equal(seen, test.seen) {
+				t.Errorf("states seen by middleware = %q; want %q", seen, test.seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package tray
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"deedles.dev/tray/internal/set"
+)
+
+// MenuSpec is a declarative description of a single item in a menu
+// hierarchy. A tree of MenuSpecs can be applied to a [Menu] with
+// [Menu.Reconcile].
+type MenuSpec struct {
+	// Key identifies the item among its siblings across calls to
+	// Reconcile. An existing item with the same key under the same
+	// parent is updated in place instead of being recreated. Items with
+	// an empty key are instead matched by their position among their
+	// siblings that also have empty keys. Keys must be unique among
+	// siblings.
+	Key string
+
+	// Label, if not empty, sets the item's "label" property. It is
+	// applied before Props.
+	Label string
+
+	// Handler is the item's event handler.
+	Handler MenuEventHandler
+
+	// Props are the item's properties. Any properties that the item
+	// currently has that are not set by Props, aside from the ones
+	// that are managed automatically, are removed.
+	Props []MenuItemProp
+
+	// Children are the item's children, in order.
+	Children []MenuSpec
+}
+
+// Key returns the key that the item was given by [Menu.Reconcile]. It
+// returns an empty string if the item was not created by Reconcile.
+func (item *MenuItem) Key() string {
+	item.m.RLock()
+	defer item.m.RUnlock()
+
+	return item.key
+}
+
+// Reconcile updates the menu hierarchy to match spec, which describes
+// the complete list of top-level items. Rather than rebuilding the
+// menu from scratch, it compares spec against the current hierarchy
+// and applies the minimal set of additions, removals, moves, and
+// property updates necessary. This includes removing items that were
+// not created by Reconcile.
+//
+// All of the changes are applied at once, resulting in a single
+// revision of the layout. A LayoutUpdated signal is emitted for each
+// parent whose children changed and one ItemsPropertiesUpdated signal
+// is emitted for all property changes to items that already existed.
+func (menu *Menu) Reconcile(spec []MenuSpec) error {
+	err := validateSpec(spec, "")
+	if err != nil {
+		return err
+	}
+
+	defer menu.lock()()
+
+	r := reconciler{menu: menu}
+	r.children(menu, false, spec)
+	return r.flush(menu, r.layout...)
+}
+
+func validateSpec(spec []MenuSpec, path string) error {
+	keys := make(set.Set[string], len(spec))
+	for i, s := range spec {
+		p := path + "[" + strconv.FormatInt(int64(i), 10) + "]"
+		if s.Key != "" {
+			if keys.Contains(s.Key) {
+				return fmt.Errorf("menu spec %v: duplicate key %q", p, s.Key)
+			}
+			keys.Add(s.Key)
+		}
+
+		err := validateSpec(s.Children, p+".Children")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type reconciler struct {
+	menuChanges
+	menu *Menu
+}
+
+// matchKey returns the key used to match items against specs. Items
+// without an explicit key are matched by their position among their
+// unkeyed siblings, so that position is encoded into a key that can't
+// collide with an explicit one.
+func matchKey(key string, unkeyed *int) string {
+	if key != "" {
+		return "k" + key
+	}
+	*unkeyed++
+	return "u" + strconv.FormatInt(int64(*unkeyed), 10)
+}
+
+func (r *reconciler) children(parent menuNode, parentIsNew bool, specs []MenuSpec) {
+	if item, ok := parent.(*MenuItem); ok {
+		defer item.lock()()
+	}
+
+	live := parent.getChildren()
+	existing := make(map[string]*MenuItem, len(live))
+	var unkeyed int
+	for _, id := range live {
+		child := r.menu.nodes[id]
+		if child != nil {
+			existing[matchKey(child.key, &unkeyed)] = child
+		}
+	}
+
+	ids := make([]int, 0, len(specs))
+	unkeyed = 0
+	for _, spec := range specs {
+		key := matchKey(spec.Key, &unkeyed)
+		child, ok := existing[key]
+		if ok {
+			delete(existing, key)
+		} else {
+			child = r.menu.newItem(parent.getID())
+			child.key = spec.Key
+		}
+
+		r.item(child, !ok, spec)
+		ids = append(ids, child.id)
+	}
+
+	for _, child := range existing {
+		r.menu.detachNode(child)
+	}
+
+	if !slices.Equal(live, ids) {
+		parent.setChildren(ids)
+		if !parentIsNew {
+			r.markLayout(parent.getID())
+		}
+	}
+}
+
+func (r *reconciler) item(item *MenuItem, isNew bool, spec MenuSpec) {
+	unlock := item.lock()
+
+	old := item.props
+	item.props = make(map[string]any, len(old))
+	keep := []string{"children-display"}
+	if item.radio != nil {
+		keep = append(keep, "toggle-type", "toggle-state")
+	}
+	for _, k := range keep {
+		if v, ok := old[k]; ok {
+			item.props[k] = v
+		}
+	}
+	item.handler = spec.Handler
+	item.aboutToShow = nil
+	item.submenu = false
+
+	props := spec.Props
+	if spec.Label != "" {
+		props = append([]MenuItemProp{MenuItemLabel(spec.Label)}, props...)
+	}
+	_, _, errs := item.applyProps(props)
+	r.errs = append(r.errs, errs...)
+	item.updateChildrenDisplay()
+
+	if !isNew {
+		r.diff(item, old)
+	}
+
+	unlock()
+
+	r.children(item, isNew, spec.Children)
+}
+
+func (r *reconciler) diff(item *MenuItem, old map[string]any) {
+	updated := make(map[string]any)
+	for k, v := range item.props {
+		ov, ok := old[k]
+		if !ok || !reflect.DeepEqual(ov, v) {
+			updated[k] = v
+		}
+	}
+
+	var removed []string
+	for k := range old {
+		_, ok := item.props[k]
+		if !ok {
+			removed = append(removed, k)
+		}
+	}
+
+	if len(updated) != 0 {
+		r.updated = append(r.updated, menuProps{ID: item.id, Properties: updated})
+	}
+	if len(removed) != 0 {
+		slices.Sort(removed)
+		r.removed = append(r.removed, menuRemovedProps{ID: item.id, Properties: removed})
+	}
+	if len(updated) != 0 || len(removed) != 0 {
+		r.menu.dirty.mark(item.parent)
+	}
+}
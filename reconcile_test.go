@@ -0,0 +1,155 @@
+package tray
+
+import (
+	"slices"
+	"testing"
+)
+
+// specKey identifies an item by its key or, if it has none, by its
+// label.
+func specKey(item *MenuItem) string {
+	if item.key != "" {
+		return item.key
+	}
+	return itemLabel(item)
+}
+
+func keyed(keys ...string) []MenuSpec {
+	specs := make([]MenuSpec, 0, len(keys))
+	for _, key := range keys {
+		specs = append(specs, MenuSpec{Key: key, Label: key})
+	}
+	return specs
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []MenuSpec
+		next    []MenuSpec
+		layout  string
+		signals []string
+	}{
+		{
+			name:    "Unchanged",
+			initial: keyed("a", "b"),
+			next:    keyed("a", "b"),
+			layout:  "a b",
+		},
+		{
+			name:    "Insert",
+			initial: keyed("a", "b"),
+			next:    keyed("a", "x", "b"),
+			layout:  "a x b",
+			signals: []string{"layout root"},
+		},
+		{
+			name:    "Remove",
+			initial: keyed("a", "b", "c"),
+			next:    keyed("a", "c"),
+			layout:  "a c",
+			signals: []string{"layout root"},
+		},
+		{
+			name:    "Reorder",
+			initial: keyed("a", "b", "c"),
+			next:    keyed("c", "a", "b"),
+			layout:  "c a b",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "PropDiff",
+			initial: []MenuSpec{
+				{Key: "a", Label: "A", Props: []MenuItemProp{MenuItemEnabled(false)}},
+				{Key: "b", Label: "B", Props: []MenuItemProp{MenuItemVisible(false)}},
+			},
+			next: []MenuSpec{
+				{Key: "a", Label: "A2"},
+				{Key: "b", Label: "B", Props: []MenuItemProp{MenuItemVisible(false)}},
+			},
+			layout:  "a b",
+			signals: []string{`props a.label="A2" -a.enabled`},
+		},
+		{
+			name: "Nested",
+			initial: []MenuSpec{
+				{Key: "a", Children: keyed("b", "c")},
+				{Key: "d", Children: keyed("e")},
+			},
+			next: []MenuSpec{
+				{Key: "a", Children: keyed("c", "x", "b")},
+				{Key: "d", Children: keyed("e")},
+			},
+			layout:  "a(c x b) d(e)",
+			signals: []string{"layout a"},
+		},
+		{
+			name: "NewSubtree",
+			initial: []MenuSpec{
+				{Key: "a"},
+			},
+			next: []MenuSpec{
+				{Key: "a"},
+				{Key: "b", Children: []MenuSpec{{Key: "c", Children: keyed("d")}}},
+			},
+			layout:  "a b(c(d))",
+			signals: []string{"layout root"},
+		},
+		{
+			name:    "Unkeyed",
+			initial: []MenuSpec{{Label: "X"}, {Label: "Y"}},
+			next:    []MenuSpec{{Label: "Y"}},
+			layout:  "Y",
+			signals: []string{"layout root", `props Y.label="Y"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+			menu := item.menu
+
+			err := menu.Reconcile(test.initial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := make(map[string]*MenuItem)
+			for _, item := range menu.nodes {
+				if item.key != "" {
+					before[item.key] = item
+				}
+			}
+			bus.signals(t)
+
+			err = menu.Reconcile(test.next)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if layout := renderMenu(menu, specKey); layout != test.layout {
+				t.Errorf("layout = %q; want %q", layout, test.layout)
+			}
+			if sigs := bus.menuSignals(t, menu, specKey); !slices.Equal(sigs, test.signals) {
+				t.Errorf("signals = %q; want %q", sigs, test.signals)
+			}
+			for _, item := range menu.nodes {
+				old, ok := before[item.key]
+				if ok && old != item {
+					t.Errorf("item %q was recreated", item.key)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileDuplicateKeys(t *testing.T) {
+	item, _ := newTestItem(t)
+
+	err := item.menu.Reconcile([]MenuSpec{{Key: "a", Children: keyed("b", "b")}})
+	if err == nil {
+		t.Fatal("duplicate keys were accepted")
+	}
+	if len(item.menu.children) != 0 {
+		t.Fatal("menu was changed despite the error")
+	}
+}
@@ -0,0 +1,168 @@
+package tray
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// parentLabels renders the parents of items as reported by Parent,
+// such as "a=- b=a".
+func parentLabels(items map[string]*MenuItem) string {
+	parts := make([]string, 0, len(items))
+	for _, label := range slices.Sorted(maps.Keys(items)) {
+		parent := "-"
+		if p := items[label].Parent(); p != nil {
+			parent = itemLabel(p)
+		}
+		parts = append(parts, label+"="+parent)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestMenuItemRemove(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func(menu *Menu, items map[string]*MenuItem) error
+		err     error
+		layout  string
+		parents string
+		signals []string
+	}{
+		{
+			name: "Remove",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["a"].Remove()
+			},
+			parents: "a=- b=- c=- d=-",
+			layout:  "b c",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "RemoveOnlyChild",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				return items["d"].Remove()
+			},
+			parents: "a=- b=- c=- d=-",
+			layout:  "a b c",
+			signals: []string{"layout a"},
+		},
+		{
+			name: "RemoveTwice",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				return items["a"].Remove()
+			},
+			err:     ErrDetached,
+			parents: "a=- b=- c=- d=-",
+			layout:  "b c",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "Reattach",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				return menu.AppendChild(items["a"])
+			},
+			parents: "a=- b=- c=- d=a",
+			layout:  "b c a(d)",
+			signals: []string{"layout root", "layout root"},
+		},
+		{
+			name: "ReattachBefore",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				return items["a"].MoveBefore(items["c"])
+			},
+			parents: "a=- b=- c=- d=a",
+			layout:  "b a(d) c",
+			signals: []string{"layout root", "layout root"},
+		},
+		{
+			name: "ReattachNested",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["b"].Remove()
+				return items["d"].AppendChild(items["b"])
+			},
+			parents: "a=- b=d c=- d=a",
+			layout:  "a(d(b)) c",
+			signals: []string{"layout root", "layout d"},
+		},
+		{
+			name: "ReattachDescendant",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				return menu.AppendChild(items["d"])
+			},
+			err:     ErrDetached,
+			parents: "a=- b=- c=- d=-",
+			layout:  "b c",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "AddToDetached",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				_, err := items["a"].AddChild(MenuItemLabel("x"))
+				return err
+			},
+			err:     ErrDetached,
+			parents: "a=- b=- c=- d=-",
+			layout:  "b c",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "MoveBeforeDetached",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				return items["b"].MoveBefore(items["a"])
+			},
+			err:     ErrDetached,
+			parents: "a=- b=- c=- d=-",
+			layout:  "b c",
+			signals: []string{"layout root"},
+		},
+		{
+			name: "SetDetachedDescendant",
+			op: func(menu *Menu, items map[string]*MenuItem) error {
+				items["a"].Remove()
+				return items["d"].SetProps(MenuItemLabel("x"))
+			},
+			err:     ErrDetached,
+			parents: "a=- b=- c=- d=-",
+			layout:  "b c",
+			signals: []string{"layout root"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, bus := newTestItem(t)
+			menu := item.menu
+
+			items := make(map[string]*MenuItem)
+			for _, label := range []string{"a", "b", "c"} {
+				items[label], _ = menu.AddChild(MenuItemLabel(label))
+			}
+			items["d"], _ = items["a"].AddChild(MenuItemLabel("d"))
+			bus.signals(t)
+
+			err := test.op(menu, items)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v; want %v", err, test.err)
+			}
+
+			if layout := renderMenu(menu, itemLabel); layout != test.layout {
+				t.Errorf("layout = %q; want %q", layout, test.layout)
+			}
+			if parents := parentLabels(items); parents != test.parents {
+				t.Errorf("parents = %q; want %q", parents, test.parents)
+			}
+			if sigs := bus.menuSignals(t, menu, itemLabel); !slices.Equal(sigs, test.signals) {
+				t.Errorf("signals = %q; want %q", sigs, test.signals)
+			}
+		})
+	}
+}
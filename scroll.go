@@ -0,0 +1,270 @@
+package tray
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultScrollStepDelta          = 120
+	defaultScrollAccelerationWindow = 150 * time.Millisecond
+)
+
+// Scroller normalizes the scroll events sent to [Handler.Scroll] into
+// discrete steps. Different environments send very different deltas
+// for what is physically the same action. Some send multiples of 120
+// for each notch of a mouse wheel, others send ±1, and touchpads tend
+// to send many small values. Scroller accumulates deltas separately
+// for each [Orientation] and calls OnStep whenever enough of them have
+// been accumulated to make up at least one whole step. For
+// environments that report each notch as ±1, set Discrete.
+//
+// The zero value is ready to use once OnStep has been set. Its Scroll
+// method can be used directly as [HandlerFuncs.OnScroll]:
+//
+//	volume := tray.ScrollValue{Item: item, Max: 100, Step: 5}
+//	scroller := tray.Scroller{OnStep: volume.Steps}
+//	tray.ItemHandler(tray.HandlerFuncs{OnScroll: scroller.Scroll})
+//
+// A Scroller must not be copied after first use.
+type Scroller struct {
+	// StepDelta is the accumulated delta that makes up a single step.
+	// If it is zero, 120 is used.
+	StepDelta int
+
+	// Discrete makes every scroll count as exactly one step in the
+	// direction of its delta, regardless of its magnitude. It is meant
+	// for environments that report each notch of a mouse wheel as ±1
+	// rather than as a fraction of StepDelta.
+	Discrete bool
+
+	// Invert reverses the direction of all steps.
+	Invert bool
+
+	// Acceleration, if greater than 1, multiplies the number of steps
+	// of a scroll that arrives within AccelerationWindow of the
+	// previous step in the same orientation.
+	Acceleration float64
+
+	// AccelerationWindow is the maximum amount of time between steps
+	// for Acceleration to apply. If it is zero, 150 milliseconds is
+	// used.
+	AccelerationWindow time.Duration
+
+	// OnStep is called with the number of steps, which is positive or
+	// negative depending on direction, whenever at least one whole step
+	// has been accumulated.
+	OnStep func(steps int, orientation Orientation) error
+
+	m     sync.Mutex
+	state map[Orientation]*scrollState
+}
+
+type scrollState struct {
+	acc  int
+	last time.Time
+}
+
+func (s *Scroller) stepDelta() int {
+	if s.StepDelta <= 0 {
+		return defaultScrollStepDelta
+	}
+	return s.StepDelta
+}
+
+func (s *Scroller) accelerationWindow() time.Duration {
+	if s.AccelerationWindow <= 0 {
+		return defaultScrollAccelerationWindow
+	}
+	return s.AccelerationWindow
+}
+
+func (s *Scroller) accumulate(delta int, orientation Orientation) int {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.state == nil {
+		s.state = make(map[Orientation]*scrollState)
+	}
+	state := s.state[orientation]
+	if state == nil {
+		state = &scrollState{}
+		s.state[orientation] = state
+	}
+
+	step := s.stepDelta()
+	if s.Discrete {
+		delta = cmp.Compare(delta, 0) * step
+	}
+	if s.Invert {
+		delta = -delta
+	}
+
+	// Reset on a change of direction so that reversing doesn't first
+	// have to cancel out whatever is left over from before.
+	if (state.acc < 0) != (delta < 0) {
+		state.acc = 0
+	}
+
+	state.acc += delta
+	steps := state.acc / step
+	if steps == 0 {
+		return 0
+	}
+	state.acc -= steps * step
+
+	now := time.Now()
+	if s.Acceleration > 1 && now.Sub(state.last) < s.accelerationWindow() {
+		steps = int(math.Round(float64(steps) * s.Acceleration))
+	}
+	state.last = now
+
+	return steps
+}
+
+// Scroll accumulates delta and calls OnStep if enough has been
+// accumulated. It has the same signature as [Handler.Scroll].
+func (s *Scroller) Scroll(delta int, orientation Orientation) error {
+	steps := s.accumulate(delta, orientation)
+	if steps == 0 || s.OnStep == nil {
+		return nil
+	}
+	return s.OnStep(steps, orientation)
+}
+
+// Reset discards any partially accumulated steps.
+func (s *Scroller) Reset() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	clear(s.state)
+}
+
+// ScrollValue is a numeric value that is adjusted by scroll steps,
+// such as a volume level. Its Steps method can be used as
+// [Scroller.OnStep]. If Item is not nil, the item's tooltip is
+// updated to reflect the value whenever it changes.
+//
+// A ScrollValue must not be copied after first use.
+type ScrollValue struct {
+	// Item, if not nil, has its tooltip updated whenever the value
+	// changes.
+	Item *Item
+
+	// Orientation, if not empty, causes steps in other orientations
+	// to be ignored.
+	Orientation Orientation
+
+	// Min and Max are the bounds of the value. If both are zero, the
+	// value is unbounded.
+	Min, Max int
+
+	// Step is the amount that the value changes by per step. If it is
+	// zero, 1 is used.
+	Step int
+
+	// Format returns the tooltip title and description for the given
+	// value. If it is nil, the title is left as is and the description
+	// is set to the value.
+	Format func(value int) (title, description string)
+
+	// OnChange, if not nil, is called with the new value whenever it
+	// changes. Changes are reported in the order in which they happen,
+	// so OnChange must not call Set or Steps.
+	OnChange func(value int) error
+
+	// changeM is held while a change is being applied so that the
+	// tooltip and OnChange see the changes in order. m is separate so
+	// that Value can be called meanwhile.
+	changeM sync.Mutex
+
+	m     sync.Mutex
+	value int
+}
+
+// Value returns the current value.
+func (v *ScrollValue) Value() int {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return v.value
+}
+
+func (v *ScrollValue) clamp(value int) int {
+	if v.Min == 0 && v.Max == 0 {
+		return value
+	}
+	return min(max(value, v.Min), v.Max)
+}
+
+// apply sets the value to the result of calling f with the current
+// one and, if that changes it, updates the tooltip and calls OnChange
+// before any other change can be applied.
+func (v *ScrollValue) apply(f func(int) int) error {
+	v.changeM.Lock()
+	defer v.changeM.Unlock()
+
+	value, changed := v.update(f)
+	if !changed {
+		return nil
+	}
+	return v.changed(value)
+}
+
+func (v *ScrollValue) update(f func(int) int) (int, bool) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	value := v.clamp(f(v.value))
+	if value == v.value {
+		return value, false
+	}
+	v.value = value
+	return value, true
+}
+
+// Set sets the value, clamping it to the bounds if necessary. The
+// tooltip is updated and OnChange is called only if the value
+// actually changes.
+func (v *ScrollValue) Set(value int) error {
+	return v.apply(func(int) int { return value })
+}
+
+// Steps adjusts the value by the given number of steps. It has the
+// same signature as [Scroller.OnStep].
+func (v *ScrollValue) Steps(steps int, orientation Orientation) error {
+	if v.Orientation != "" && orientation != v.Orientation {
+		return nil
+	}
+
+	step := v.Step
+	if step == 0 {
+		step = 1
+	}
+
+	return v.apply(func(value int) int { return value + steps*step })
+}
+
+func (v *ScrollValue) changed(value int) error {
+	if v.Item != nil {
+		iconName, iconPixmap, title, description := v.Item.ToolTip()
+		if v.Format != nil {
+			title, description = v.Format(value)
+		} else {
+			description = strconv.FormatInt(int64(value), 10)
+		}
+
+		err := v.Item.SetProps(ItemToolTip(iconName, iconPixmap, title, description))
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.OnChange != nil {
+		return v.OnChange(value)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package tray
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScrollerAccumulate(t *testing.T) {
+	tests := []struct {
+		name     string
+		scroller *Scroller
+		deltas   []int
+		delay    time.Duration
+		want     []int
+	}{
+		{
+			name:   "Notches",
+			deltas: []int{120, 240, -120},
+			want:   []int{1, 2, -1},
+		},
+		{
+			name:   "Unit",
+			deltas: []int{1, 1, -1},
+			want:   []int{0, 0, 0},
+		},
+		{
+			name:   "Mixed",
+			deltas: []int{2, 1, 2, 115, 1},
+			want:   []int{0, 0, 0, 1, 0},
+		},
+		{
+			name:     "Discrete",
+			scroller: &Scroller{Discrete: true},
+			deltas:   []int{1, 1, -1, 0},
+			want:     []int{1, 1, -1, 0},
+		},
+		{
+			name:     "DiscreteMixed",
+			scroller: &Scroller{Discrete: true},
+			deltas:   []int{2, 1, 2, 240},
+			want:     []int{1, 1, 1, 1},
+		},
+		{
+			name:   "Fine",
+			deltas: []int{30, 30, 30, 30, 100, 20},
+			want:   []int{0, 0, 0, 1, 0, 1},
+		},
+		{
+			name:   "Remainder",
+			deltas: []int{200, 40},
+			want:   []int{1, 1},
+		},
+		{
+			name:   "Reverse",
+			deltas: []int{100, -100, -20},
+			want:   []int{0, 0, -1},
+		},
+		{
+			name:     "StepDelta",
+			scroller: &Scroller{StepDelta: 10},
+			deltas:   []int{25, 5, 1},
+			want:     []int{2, 1, 0},
+		},
+		{
+			name:     "Invert",
+			scroller: &Scroller{Invert: true},
+			deltas:   []int{120, -120},
+			want:     []int{-1, 1},
+		},
+		{
+			name:     "Acceleration",
+			scroller: &Scroller{Acceleration: 2.5, AccelerationWindow: time.Hour},
+			deltas:   []int{120, 120, 240},
+			want:     []int{1, 3, 5},
+		},
+		{
+			name:     "NoAcceleration",
+			scroller: &Scroller{Acceleration: 2, AccelerationWindow: time.Nanosecond},
+			deltas:   []int{120, 120},
+			delay:    time.Millisecond,
+			want:     []int{1, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.scroller
+			if s == nil {
+				s = new(Scroller)
+			}
+			got := make([]int, 0, len(test.deltas))
+			for _, delta := range test.deltas {
+				time.Sleep(test.delay)
+				got = append(got, s.accumulate(delta, Vertical))
+			}
+			if !slices.Equal(got, test.want) {
+				t.Fatalf("steps for %v = %v; want %v", test.deltas, got, test.want)
+			}
+		})
+	}
+}
+
+func TestScrollerOrientations(t *testing.T) {
+	var s Scroller
+	if steps := s.accumulate(60, Vertical); steps != 0 {
+		t.Fatalf("vertical steps = %v", steps)
+	}
+	if steps := s.accumulate(60, Horizontal); steps != 0 {
+		t.Fatalf("horizontal steps = %v; deltas of different orientations were combined", steps)
+	}
+	if steps := s.accumulate(60, Vertical); steps != 1 {
+		t.Fatalf("vertical steps = %v; want 1", steps)
+	}
+
+	s.Reset()
+	if steps := s.accumulate(60, Horizontal); steps != 0 {
+		t.Fatalf("horizontal steps after reset = %v", steps)
+	}
+}
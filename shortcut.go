@@ -0,0 +1,205 @@
+package tray
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// KeyModifier is a modifier key that can be part of a [KeyChord].
+type KeyModifier string
+
+// These are the modifiers that are allowed by the dbusmenu
+// specification.
+const (
+	Control KeyModifier = "Control"
+	Alt     KeyModifier = "Alt"
+	Shift   KeyModifier = "Shift"
+	Super   KeyModifier = "Super"
+)
+
+// modifierNames maps the lowercase names accepted by ParseShortcut to
+// modifiers.
+var modifierNames = map[string]KeyModifier{
+	"control": Control,
+	"ctrl":    Control,
+	"alt":     Alt,
+	"shift":   Shift,
+	"super":   Super,
+	"win":     Super,
+}
+
+// Valid returns true if m is one of the modifiers allowed by the
+// dbusmenu specification.
+func (m KeyModifier) Valid() bool {
+	switch m {
+	case Control, Alt, Shift, Super:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns a human-readable name for the modifier.
+func (m KeyModifier) String() string {
+	if m == Control {
+		return "Ctrl"
+	}
+	return string(m)
+}
+
+// KeyChord is a single key press, with modifiers, that is part of a
+// [Shortcut].
+type KeyChord struct {
+	Modifiers []KeyModifier
+
+	// Key is the name of the non-modifier key, such as "q" or "F5".
+	Key string
+}
+
+// Validate returns an error if c is not a valid chord. A valid chord
+// has a non-empty key containing no whitespace and only valid
+// modifiers, none of which are repeated.
+func (c KeyChord) Validate() error {
+	if c.Key == "" {
+		return errors.New("missing key")
+	}
+	if strings.ContainsFunc(c.Key, unicode.IsSpace) {
+		return fmt.Errorf("key %q contains whitespace", c.Key)
+	}
+	if _, ok := modifierNames[strings.ToLower(c.Key)]; ok {
+		return fmt.Errorf("key %q is a modifier", c.Key)
+	}
+
+	for i, m := range c.Modifiers {
+		if !m.Valid() {
+			return fmt.Errorf("invalid modifier %q", string(m))
+		}
+		if slices.Contains(c.Modifiers[:i], m) {
+			return fmt.Errorf("duplicate modifier %q", string(m))
+		}
+	}
+
+	return nil
+}
+
+// String returns c in the form accepted by [ParseShortcut], such as
+// "Ctrl+Shift+Q".
+func (c KeyChord) String() string {
+	var sb strings.Builder
+	for _, m := range c.Modifiers {
+		sb.WriteString(m.String())
+		sb.WriteByte('+')
+	}
+	sb.WriteString(c.Key)
+	return sb.String()
+}
+
+// Shortcut is a keyboard shortcut consisting of one or more chords that
+// are pressed in sequence.
+type Shortcut []KeyChord
+
+// ParseShortcut parses a shortcut from a string of the form
+// "Ctrl+Shift+Q". Multiple chords are separated by whitespace, as in
+// "Ctrl+K Ctrl+S". Modifier names are case-insensitive and may be
+// given as "Ctrl" or "Control", "Alt", "Shift", and "Super" or "Win".
+// The last part of each chord is the key, which may itself be "+".
+func ParseShortcut(str string) (Shortcut, error) {
+	fields := strings.Fields(str)
+	if len(fields) == 0 {
+		return nil, errors.New("parse shortcut: empty shortcut")
+	}
+
+	shortcut := make(Shortcut, 0, len(fields))
+	for _, field := range fields {
+		chord, err := parseKeyChord(field)
+		if err != nil {
+			return nil, fmt.Errorf("parse shortcut %q: %w", str, err)
+		}
+		shortcut = append(shortcut, chord)
+	}
+	return shortcut, nil
+}
+
+func parseKeyChord(str string) (KeyChord, error) {
+	key := str
+	var mods string
+	if strings.HasSuffix(str, "++") {
+		key = "+"
+		mods = strings.TrimSuffix(str, "++")
+	} else if i := strings.LastIndexByte(str, '+'); i >= 0 && str != "+" {
+		key = str[i+1:]
+		mods = str[:i]
+	}
+
+	var chord KeyChord
+	if mods != "" {
+		for name := range strings.SplitSeq(mods, "+") {
+			m, ok := modifierNames[strings.ToLower(name)]
+			if !ok {
+				return chord, fmt.Errorf("unknown modifier %q", name)
+			}
+			chord.Modifiers = append(chord.Modifiers, m)
+		}
+	}
+	chord.Key = key
+
+	return chord, chord.Validate()
+}
+
+// Validate returns an error if s is empty or if any of its chords are
+// invalid.
+func (s Shortcut) Validate() error {
+	if len(s) == 0 {
+		return errors.New("empty shortcut")
+	}
+	for i, c := range s {
+		err := c.Validate()
+		if err != nil {
+			return fmt.Errorf("chord %v: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// String returns s in the form accepted by [ParseShortcut], such as
+// "Ctrl+K Ctrl+S".
+func (s Shortcut) String() string {
+	chords := make([]string, 0, len(s))
+	for _, c := range s {
+		chords = append(chords, c.String())
+	}
+	return strings.Join(chords, " ")
+}
+
+// wire returns s in the format used by the "shortcut" property.
+func (s Shortcut) wire() [][]string {
+	w := make([][]string, 0, len(s))
+	for _, c := range s {
+		keys := make([]string, 0, len(c.Modifiers)+1)
+		for _, m := range c.Modifiers {
+			keys = append(keys, string(m))
+		}
+		w = append(w, append(keys, c.Key))
+	}
+	return w
+}
+
+// shortcutFromWire converts the format used by the "shortcut" property
+// into a Shortcut. It does not validate the result.
+func shortcutFromWire(w [][]string) Shortcut {
+	s := make(Shortcut, 0, len(w))
+	for _, keys := range w {
+		var c KeyChord
+		if len(keys) != 0 {
+			for _, m := range keys[:len(keys)-1] {
+				c.Modifiers = append(c.Modifiers, KeyModifier(m))
+			}
+			c.Key = keys[len(keys)-1]
+		}
+		s = append(s, c)
+	}
+	return s
+}
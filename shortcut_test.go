@@ -0,0 +1,163 @@
+package tray
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShortcut(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Shortcut
+		err  string
+	}{
+		{name: "Key", in: "q", want: Shortcut{{Key: "q"}}},
+		{name: "Modifiers", in: "Ctrl+Shift+Q", want: Shortcut{{Modifiers: []KeyModifier{Control, Shift}, Key: "Q"}}},
+		{name: "Aliases", in: "control+ALT+win+F5", want: Shortcut{{Modifiers: []KeyModifier{Control, Alt, Super}, Key: "F5"}}},
+		{name: "Sequence", in: "Ctrl+K  Ctrl+S", want: Shortcut{
+			{Modifiers: []KeyModifier{Control}, Key: "K"},
+			{Modifiers: []KeyModifier{Control}, Key: "S"},
+		}},
+		{name: "PlusKey", in: "Ctrl++", want: Shortcut{{Modifiers: []KeyModifier{Control}, Key: "+"}}},
+		{name: "PlusAlone", in: "+", want: Shortcut{{Key: "+"}}},
+		{name: "MissingKey", in: "Ctrl+", err: "missing key"},
+		{name: "Empty", in: "  ", err: "empty shortcut"},
+		{name: "UnknownModifier", in: "Hyper+Q", err: `unknown modifier "Hyper"`},
+		{name: "DuplicateModifier", in: "Ctrl+Control+Q", err: `duplicate modifier "Control"`},
+		{name: "ModifierAsKey", in: "Ctrl+Shift", err: `key "Shift" is a modifier`},
+		{name: "EmptyModifier", in: "Ctrl++Q", err: `unknown modifier ""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseShortcut(test.in)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("ParseShortcut(%q) = %v, %v; want error containing %q", test.in, got, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseShortcut(%q): %v", test.in, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ParseShortcut(%q) = %#v; want %#v", test.in, got, test.want)
+			}
+		})
+	}
+}
+
+func TestShortcutString(t *testing.T) {
+	tests := []string{
+		"q",
+		"Ctrl+Shift+Q",
+		"Ctrl+K Ctrl+S",
+		"Ctrl++",
+		"+",
+	}
+
+	for _, in := range tests {
+		s, err := ParseShortcut(in)
+		if err != nil {
+			t.Fatalf("ParseShortcut(%q): %v", in, err)
+		}
+		if got := s.String(); got != in {
+			t.Errorf("ParseShortcut(%q).String() = %q", in, got)
+		}
+		if got := shortcutFromWire(s.wire()); !reflect.DeepEqual(got, s) {
+			t.Errorf("shortcutFromWire(%q.wire()) = %#v", in, got)
+		}
+	}
+}
+
+func TestMenuItemShortcut(t *testing.T) {
+	tests := []struct {
+		name string
+		prop MenuItemProp
+		want [][]string
+		err  string
+	}{
+		{
+			name: "Raw",
+			prop: MenuItemShortcut([][]string{{"Control", "q"}}),
+			want: [][]string{{"Control", "q"}},
+		},
+		{
+			name: "RawPassThrough",
+			prop: MenuItemShortcut([][]string{{"Hyper", "Control"}}),
+			want: [][]string{{"Hyper", "Control"}},
+		},
+		{
+			name: "RawEmpty",
+			prop: MenuItemShortcut(nil),
+		},
+		{
+			name: "Key",
+			prop: MenuItemKeyShortcut(Shortcut{{Modifiers: []KeyModifier{Control}, Key: "q"}}),
+			want: [][]string{{"Control", "q"}},
+		},
+		{
+			name: "KeyInvalid",
+			prop: MenuItemKeyShortcut(Shortcut{{Modifiers: []KeyModifier{Control}}}),
+			want: [][]string{{"Alt", "x"}},
+			err:  "missing key",
+		},
+		{
+			name: "KeyEmpty",
+			prop: MenuItemKeyShortcut(nil),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item, _ := newTestItem(t)
+			c, err := item.menu.AddChild(MenuItemShortcut([][]string{{"Alt", "x"}}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.SetProps(test.prop)
+			if test.err == "" && err != nil {
+				t.Errorf("error = %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("error = %v; want error containing %q", err, test.err)
+			}
+			if got := c.Shortcut(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("shortcut = %q; want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMenuSpecShortcut(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want [][]string
+	}{
+		{name: "String", in: `"Ctrl+Q"`, want: [][]string{{"Control", "Q"}}},
+		{name: "Array", in: `[["Control", "q"]]`, want: [][]string{{"Control", "q"}}},
+		{name: "ArrayPassThrough", in: `[["Hyper"]]`, want: [][]string{{"Hyper"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec, err := ParseMenuSpec(strings.NewReader(`{"items": [{"shortcut": `+test.in+`}]}`), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			item, _ := newTestItem(t)
+			err = item.menu.Reconcile(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := item.menu.Children()[0].Shortcut(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("shortcut = %q; want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package tray
 
-import "github.com/godbus/dbus/v5"
+import (
+	"errors"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
 
 // Handler specifies behavior for incoming events for a
 // StatusNotifierItem. In most cases, Activate is the only method of
@@ -12,7 +17,10 @@ import "github.com/godbus/dbus/v5"
 // in the first place. In a lot of cases, this won't do much besides
 // possibly showing up in a log somewhere, so it's probably best in
 // those cases to handle it locally some other way, if appropriate,
-// and then just return nil regardless.
+// and then just return nil regardless. When using [DispatchAsync],
+// errors are instead passed to the function set via
+// [ItemErrorHandler]. To fail the call with a specific D-Bus error
+// name, return an [*Error].
 type Handler interface {
 	ContextMenu(x, y int) error
 	Activate(x, y int) error
@@ -31,64 +39,176 @@ func (h ActivateHandler) Activate(x, y int) error                         { retu
 func (h ActivateHandler) SecondaryActivate(x, y int) error                { return nil }
 func (h ActivateHandler) Scroll(delta int, orientation Orientation) error { return nil }
 
+// HandlerFuncs is a [Handler] built from a set of optional functions,
+// one for each method of Handler. This is useful for the case of
+// needing more than just Activate but not all of the methods.
+//
+// If a function field is nil, the corresponding method is passed
+// through to Fallback instead, allowing a HandlerFuncs to be used to
+// override specific events of another Handler. If Fallback is also
+// nil, the method does nothing.
+type HandlerFuncs struct {
+	OnContextMenu       func(x, y int) error
+	OnActivate          func(x, y int) error
+	OnSecondaryActivate func(x, y int) error
+	OnScroll            func(delta int, orientation Orientation) error
+
+	Fallback Handler
+}
+
+func (h HandlerFuncs) ContextMenu(x, y int) error {
+	switch {
+	case h.OnContextMenu != nil:
+		return h.OnContextMenu(x, y)
+	case h.Fallback != nil:
+		return h.Fallback.ContextMenu(x, y)
+	default:
+		return nil
+	}
+}
+
+func (h HandlerFuncs) Activate(x, y int) error {
+	switch {
+	case h.OnActivate != nil:
+		return h.OnActivate(x, y)
+	case h.Fallback != nil:
+		return h.Fallback.Activate(x, y)
+	default:
+		return nil
+	}
+}
+
+func (h HandlerFuncs) SecondaryActivate(x, y int) error {
+	switch {
+	case h.OnSecondaryActivate != nil:
+		return h.OnSecondaryActivate(x, y)
+	case h.Fallback != nil:
+		return h.Fallback.SecondaryActivate(x, y)
+	default:
+		return nil
+	}
+}
+
+func (h HandlerFuncs) Scroll(delta int, orientation Orientation) error {
+	switch {
+	case h.OnScroll != nil:
+		return h.OnScroll(delta, orientation)
+	case h.Fallback != nil:
+		return h.Fallback.Scroll(delta, orientation)
+	default:
+		return nil
+	}
+}
+
+// MultiHandler returns a [Handler] that calls each of the given
+// handlers in order for every event. All of the handlers are called
+// even if some of them return errors, and the returned error is the
+// result of joining all of those errors together. Nil handlers are
+// ignored.
+func MultiHandler(handlers ...Handler) Handler {
+	return multiHandler(slices.DeleteFunc(slices.Clone(handlers), func(h Handler) bool { return h == nil }))
+}
+
+type multiHandler []Handler
+
+func (m multiHandler) each(f func(Handler) error) error {
+	errs := make([]error, 0, len(m))
+	for _, h := range m {
+		errs = append(errs, f(h))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) ContextMenu(x, y int) error {
+	return m.each(func(h Handler) error { return h.ContextMenu(x, y) })
+}
+
+func (m multiHandler) Activate(x, y int) error {
+	return m.each(func(h Handler) error { return h.Activate(x, y) })
+}
+
+func (m multiHandler) SecondaryActivate(x, y int) error {
+	return m.each(func(h Handler) error { return h.SecondaryActivate(x, y) })
+}
+
+func (m multiHandler) Scroll(delta int, orientation Orientation) error {
+	return m.each(func(h Handler) error { return h.Scroll(delta, orientation) })
+}
+
 type statusNotifierItem Item
 
 func (item *statusNotifierItem) Handler() Handler {
 	return (*Item)(item).Handler()
 }
 
-func (item *statusNotifierItem) ContextMenu(x, y int) *dbus.Error {
+func (item *statusNotifierItem) handle(ev Event, call func(Handler) error) error {
+	return (*Item)(item).dispatch(func() error {
+		(*Item)(item).emitEvent(ev)
+
+		handler := item.Handler()
+		if handler == nil {
+			return nil
+		}
+		return (*Item)(item).invoke(ev, func() error { return call(handler) })
+	})
+}
+
+func (item *statusNotifierItem) ContextMenu(sender dbus.Sender, x, y int) *dbus.Error {
 	logger.Info("item method", "name", "ContextMenu", "x", x, "y", y)
 
-	handler := item.Handler()
-	if handler == nil {
-		return nil
+	derr := (*Item)(item).checkAccess(sender, "ContextMenu")
+	if derr != nil {
+		return derr
 	}
-	err := handler.ContextMenu(x, y)
+
+	err := item.handle(ContextMenuEvent{X: x, Y: y}, func(h Handler) error { return h.ContextMenu(x, y) })
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return makeDBusError(err)
 	}
 	return nil
 }
 
-func (item *statusNotifierItem) Activate(x, y int) *dbus.Error {
+func (item *statusNotifierItem) Activate(sender dbus.Sender, x, y int) *dbus.Error {
 	logger.Info("item method", "name", "Activate", "x", x, "y", y)
 
-	handler := item.Handler()
-	if handler == nil {
-		return nil
+	derr := (*Item)(item).checkAccess(sender, "Activate")
+	if derr != nil {
+		return derr
 	}
-	err := handler.Activate(x, y)
+
+	err := item.handle(ActivateEvent{X: x, Y: y}, func(h Handler) error { return h.Activate(x, y) })
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return makeDBusError(err)
 	}
 	return nil
 }
 
-func (item *statusNotifierItem) SecondaryActivate(x, y int) *dbus.Error {
+func (item *statusNotifierItem) SecondaryActivate(sender dbus.Sender, x, y int) *dbus.Error {
 	logger.Info("item method", "name", "SecondaryActivate", "x", x, "y", y)
 
-	handler := item.Handler()
-	if handler == nil {
-		return nil
+	derr := (*Item)(item).checkAccess(sender, "SecondaryActivate")
+	if derr != nil {
+		return derr
 	}
-	err := handler.SecondaryActivate(x, y)
+
+	err := item.handle(SecondaryActivateEvent{X: x, Y: y}, func(h Handler) error { return h.SecondaryActivate(x, y) })
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return makeDBusError(err)
 	}
 	return nil
 }
 
-func (item *statusNotifierItem) Scroll(delta int, orientation Orientation) *dbus.Error {
+func (item *statusNotifierItem) Scroll(sender dbus.Sender, delta int, orientation Orientation) *dbus.Error {
 	logger.Info("item method", "name", "Scroll", "delta", delta, "orientation", orientation)
 
-	handler := item.Handler()
-	if handler == nil {
-		return nil
+	derr := (*Item)(item).checkAccess(sender, "Scroll")
+	if derr != nil {
+		return derr
 	}
-	err := handler.Scroll(delta, orientation)
+
+	err := item.handle(ScrollEvent{Delta: delta, Orientation: orientation}, func(h Handler) error { return h.Scroll(delta, orientation) })
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return makeDBusError(err)
 	}
 	return nil
 }
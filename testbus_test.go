@@ -0,0 +1,169 @@
+package tray
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// testBus records the messages that an item created by newTestItem
+// sends over its connection. There is no actual bus on the other end,
+// so it can only be used for things that don't need replies.
+type testBus struct {
+	conn *dbus.Conn
+	msgs chan *dbus.Message
+}
+
+func newTestItem(t *testing.T) (*Item, *testBus) {
+	t.Helper()
+
+	c1, c2 := net.Pipe()
+	conn, err := dbus.NewConn(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	bus := testBus{conn: conn, msgs: make(chan *dbus.Message, 64)}
+	go func() {
+		defer close(bus.msgs)
+		for {
+			msg, err := dbus.DecodeMessage(c2)
+			if err != nil {
+				return
+			}
+			bus.msgs <- msg
+		}
+	}()
+
+	item := Item{conn: conn}
+	err = item.exportProps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = item.createMenu()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &item, &bus
+}
+
+// signals returns the signals that have been emitted since the last
+// call, in order.
+func (b *testBus) signals(t *testing.T) []*dbus.Message {
+	t.Helper()
+
+	err := b.conn.Emit("/test", "org.example.Test.Sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sigs []*dbus.Message
+	for msg := range b.msgs {
+		if msg.Headers[dbus.FieldMember].Value() == "Sync" {
+			return sigs
+		}
+		if msg.Type == dbus.TypeSignal {
+			sigs = append(sigs, msg)
+		}
+	}
+	t.Fatal("connection closed")
+	return nil
+}
+
+// menuSignals returns the signals that the menu has emitted since the
+// last call, rendered as strings using name to identify items.
+func (b *testBus) menuSignals(t *testing.T, menu *Menu, name func(*MenuItem) string) []string {
+	t.Helper()
+
+	nodeName := func(id int32) string {
+		if id == 0 {
+			return "root"
+		}
+		return name(menu.nodes[int(id)])
+	}
+
+	var sigs []string
+	for _, msg := range b.signals(t) {
+		member := msg.Headers[dbus.FieldMember].Value().(string)
+		switch member {
+		case "LayoutUpdated":
+			var revision uint32
+			var parent int32
+			err := dbus.Store(msg.Body, &revision, &parent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sigs = append(sigs, "layout "+nodeName(parent))
+
+		case "ItemsPropertiesUpdated":
+			var updated []struct {
+				ID         int32
+				Properties map[string]dbus.Variant
+			}
+			var removed []struct {
+				ID         int32
+				Properties []string
+			}
+			err := dbus.Store(msg.Body, &updated, &removed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var parts []string
+			for _, u := range updated {
+				for _, k := range sortedKeys(u.Properties) {
+					parts = append(parts, nodeName(u.ID)+"."+k+"="+u.Properties[k].String())
+				}
+			}
+			for _, r := range removed {
+				for _, k := range r.Properties {
+					parts = append(parts, "-"+nodeName(r.ID)+"."+k)
+				}
+			}
+			sigs = append(sigs, "props "+strings.Join(parts, " "))
+
+		default:
+			sigs = append(sigs, member)
+		}
+	}
+	return sigs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// renderMenu renders the structure of the menu using name to identify
+// items, such as "a(b c) d".
+func renderMenu(menu *Menu, name func(*MenuItem) string) string {
+	var render func(children []int) string
+	render = func(children []int) string {
+		parts := make([]string, 0, len(children))
+		for _, id := range children {
+			item := menu.nodes[id]
+			part := name(item)
+			if len(item.children) != 0 {
+				part += "(" + render(item.children) + ")"
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, " ")
+	}
+	return render(menu.children)
+}
+
+// itemLabel identifies an item by its label.
+func itemLabel(item *MenuItem) string {
+	label, _ := item.props["label"].(string)
+	return label
+}